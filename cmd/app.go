@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errMissingNotionKey = errors.New("NOTION_API_KEY is not set: create an integration at https://www.notion.so/my-integrations and export its secret")
+)
+
+// app lazily builds the client, config and database state shared by the commands,
+// so that commands like version and --help never touch the network.
+type app struct {
+	ctx         context.Context
+	configPath  string
+	databaseRef string
+	model       string
+	renderer    *pkg.Renderer
+
+	config     *pkg.Config
+	client     *pkg.Client
+	llmReady   bool
+	databaseID string
+	tags       []string
+}
+
+var svc = &app{}
+
+// Before records the global flags. It is run by cli.App before any command.
+func (a *app) Before(c *cli.Context) error {
+	pkg.SetupLogging()
+	a.ctx = c.Context
+	a.configPath = c.String("config")
+	a.databaseRef = c.String("database")
+	a.model = c.String("model")
+	renderer, err := pkg.NewRenderer(c.String("output"), c.App.Writer)
+	if err != nil {
+		return err
+	}
+	a.renderer = renderer
+	return nil
+}
+
+// Render writes records in the format selected with --output.
+func (a *app) Render(records any) error {
+	return a.renderer.Render(records)
+}
+
+// Table reports whether records are rendered as a table, the format meant for reading.
+func (a *app) Table() bool {
+	return a.renderer.Format == pkg.FormatTable
+}
+
+// Config loads the config file on first use.
+func (a *app) Config() (*pkg.Config, error) {
+	if a.config != nil {
+		return a.config, nil
+	}
+	cfg, err := pkg.LoadConfig(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+	a.config = cfg
+	return cfg, nil
+}
+
+// NotionClient returns a client for commands that only talk to Notion.
+func (a *app) NotionClient() (*pkg.Client, error) {
+	if os.Getenv("NOTION_API_KEY") == "" {
+		return nil, errMissingNotionKey
+	}
+	return a.newClient()
+}
+
+// LLMClient returns a client for commands that also need the LLM, set up with
+// the configured provider and model.
+func (a *app) LLMClient() (*pkg.Client, error) {
+	cfg, err := a.Config()
+	if err != nil {
+		return nil, err
+	}
+	provider := cfg.LLM.WithDefaults()
+	if provider.RequiresAPIKey() && provider.APIKey() == "" {
+		return nil, fmt.Errorf("%s is not set: export an API key for the %s provider to tag pages", provider.APIKeyEnv, provider.Provider)
+	}
+	client, err := a.NotionClient()
+	if err != nil {
+		return nil, err
+	}
+	if a.llmReady {
+		return client, nil
+	}
+
+	model := a.model
+	if model == "" {
+		model = cfg.Model
+	}
+	if model == "" {
+		model = llm.DefaultModel(provider.Provider)
+	}
+	if err := client.UseModel(model, cfg.Models); err != nil {
+		return nil, err
+	}
+	if err := client.UseProvider(provider); err != nil {
+		return nil, err
+	}
+	if cfg.Embeddings.Model != "" {
+		client.EmbeddingModel = cfg.Embeddings.Model
+	}
+	if client.Prompts, err = llm.LoadPrompts(cfg.Prompts, filepath.Dir(a.configPath)); err != nil {
+		return nil, fmt.Errorf("invalid prompts in %s: %w", a.configPath, err)
+	}
+	if client.TagMetadata, err = pkg.LoadTagMetadata(cfg.TagsPath(a.configPath)); err != nil {
+		return nil, err
+	}
+	a.llmReady = true
+	return client, nil
+}
+
+func (a *app) newClient() (*pkg.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	cfg, err := a.Config()
+	if err != nil {
+		return nil, err
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client := pkg.NewClient(ctx, "", "")
+	client.NotionClient = notionTypes.NewNotionClient(os.Getenv("NOTION_API_KEY"), cfg.RateLimit)
+	client.Pagination = cfg.Pagination
+	client.BlockTree = cfg.Blocks
+	client.Retry = cfg.LLMRetry
+	client.Examples = cfg.Examples
+	a.client = client
+	return client, nil
+}
+
+// DatabaseID resolves the database to work against from the --database flag,
+// falling back to the config file.
+func (a *app) DatabaseID() (string, error) {
+	if a.databaseID != "" {
+		return a.databaseID, nil
+	}
+	ref := a.databaseRef
+	if ref == "" {
+		cfg, err := a.Config()
+		if err != nil {
+			return "", err
+		}
+		ref = cfg.Database
+	}
+	if ref == "" {
+		return "", fmt.Errorf("no database configured: use --database, NOTION_INBOX_DATABASE_ID or set database in %s", a.configPath)
+	}
+	client, err := a.NotionClient()
+	if err != nil {
+		return "", err
+	}
+	id, err := client.ResolveDatabaseID(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve database: %w", err)
+	}
+	a.databaseID = id
+	return id, nil
+}
+
+// AvailableTags lists the tag vocabulary of the configured database on first use.
+func (a *app) AvailableTags() ([]string, error) {
+	if a.tags != nil {
+		return a.tags, nil
+	}
+	dbID, err := a.DatabaseID()
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.NotionClient()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := client.ListTagsForDatabaseColumn(dbID, "Tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags for column: %w", err)
+	}
+	a.tags = tags
+	return tags, nil
+}
@@ -3,14 +3,23 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/klauern/notion-table-reader/pkg"
 )
 
-const DatabaseID = "2ce556682898478d8e9d175badac759e"
-
 func main() {
-	tags, err := pkg.NewClient(context.Background(), "", "").ListTagsForDatabaseColumn(DatabaseID, "Tags")
+	ref := os.Getenv("NOTION_INBOX_DATABASE_ID")
+	if len(os.Args) > 1 {
+		ref = os.Args[1]
+	}
+
+	client := pkg.NewClient(context.Background(), "", "")
+	databaseID, err := client.ResolveDatabaseID(ref)
+	if err != nil {
+		panic(err)
+	}
+	tags, err := client.ListTagsForDatabaseColumn(databaseID, "Tags")
 	if err != nil {
 		panic(err)
 	}
@@ -1,38 +1,52 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/dstotijn/go-notion"
 	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
 	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
 	"github.com/urfave/cli/v2"
 )
 
-const DatabaseID = "2ce556682898478d8e9d175badac759e"
-
 var (
-	client        *pkg.Client
-	availableTags []string
-	version       = "dev"
-	commit        = "none"
-	date          = "unknown"
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
 )
 
-func init() {
-	client = pkg.NewClient(context.Background(), "", "")
-	tags, err := client.ListTagsForDatabaseColumn(DatabaseID, "Tags")
-	if err != nil {
-		panic(err)
-	}
-	availableTags = tags
-}
-
 func main() {
 	e := &cli.App{
-		Name: "notion",
+		Name:   "notion",
+		Before: svc.Before,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "Path to the config file",
+				EnvVars: []string{"NOTION_TAGGER_CONFIG"},
+				Value:   pkg.DefaultConfigPath(),
+			},
+			&cli.StringFlag{
+				Name:    "database",
+				Usage:   "Database ID, URL or title to work against",
+				EnvVars: []string{"NOTION_INBOX_DATABASE_ID"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format: " + strings.Join(pkg.OutputFormats, ", "),
+				Value:   pkg.FormatTable,
+			},
+			&cli.StringFlag{
+				Name:    "model",
+				Usage:   "LLM model tagging pages, the provider's default when unset",
+				EnvVars: []string{"NOTION_TAGGER_MODEL"},
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "database",
@@ -47,7 +61,7 @@ func main() {
 					{
 						Name:        "tags",
 						Action:      ListTags,
-						Description: "List all tags for the default DatabaseId",
+						Description: "List all tags for the configured database",
 					},
 				},
 			},
@@ -61,7 +75,23 @@ func main() {
 						Flags: []cli.Flag{
 							&cli.BoolFlag{
 								Name:  "untagged",
-								Usage: "Include untagged pages in results",
+								Usage: "Only list pages without tags",
+							},
+							&cli.BoolFlag{
+								Name:  "tagged",
+								Usage: "Only list pages with at least one tag",
+							},
+							&cli.StringSliceFlag{
+								Name:  "tag",
+								Usage: "Only list pages having this tag, can be repeated",
+							},
+							&cli.StringFlag{
+								Name:  "where",
+								Usage: `Filter expression, e.g. 'Status = "Inbox" and Created > 2026-01-01 and Tags contains "go"'`,
+							},
+							&cli.StringSliceFlag{
+								Name:  "sort",
+								Usage: "Sort by a property or created_time/last_edited_time, with an optional asc/desc, can be repeated",
 							},
 						},
 						Action: QueryPages,
@@ -74,9 +104,157 @@ func main() {
 								Name:  "page_id",
 								Usage: "Page ID to tag",
 							},
+							&cli.BoolFlag{
+								Name:  "all-untagged",
+								Usage: "Tag every page without tags",
+							},
+							&cli.StringFlag{
+								Name:  "where",
+								Usage: "Tag every page matching this filter expression, see pages query",
+							},
+							&cli.IntFlag{
+								Name:  "workers",
+								Usage: "Number of pages tagged concurrently",
+								Value: pkg.DefaultTagWorkers,
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Show the tags each page would get without writing them, --output json writes a plan for pages apply",
+							},
+							&cli.StringFlag{
+								Name:  "tagger",
+								Usage: "How to find the tags of pages: " + strings.Join(pkg.Taggers, ", ") + ", which needs tags train",
+								Value: pkg.TaggerLLM,
+							},
+							&cli.StringFlag{
+								Name:  "strategy",
+								Usage: "How to shorten pages too long for the model: " + strings.Join(llm.ContentStrategies, ", "),
+								Value: llm.StrategyTruncate,
+							},
+							&cli.IntFlag{
+								Name:  "examples",
+								Usage: "Number of pages already tagged shown to the model as examples, 0 for none",
+							},
 						},
 						Action: TagPages,
 					},
+					{
+						Name:        "apply",
+						Usage:       "apply <plan.json>",
+						Description: "Write the tags of a plan made with pages tag --dry-run --output json",
+						Args:        true,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Apply the plan to pages whose tags changed since it was made",
+							},
+						},
+						Action: ApplyPlan,
+					},
+					{
+						Name:        "search",
+						Usage:       "search <query>",
+						Description: "Search pages by meaning with a local embedding index, updated with the pages edited since the last search",
+						Args:        true,
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Number of pages returned",
+								Value: pkg.DefaultSearchLimit,
+							},
+							&cli.BoolFlag{
+								Name:  "no-update",
+								Usage: "Search the index as it is, without fetching edited pages",
+							},
+						},
+						Action: SearchPages,
+					},
+					{
+						Name:        "export",
+						Description: "Export pages to Markdown files with YAML front matter",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "dir",
+								Usage: "Directory to write the Markdown files to",
+								Value: "export",
+							},
+							&cli.StringFlag{
+								Name:  "filename",
+								Usage: "Go template for file names, with .ID, .ShortID, .Name, .Slug, .Tags, .CreatedTime and .LastEditedTime",
+								Value: pkg.DefaultExportFilename,
+							},
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Export all pages, including those not edited since the last export",
+							},
+						},
+						Action: ExportPages,
+					},
+				},
+			},
+			{
+				Name: "tags",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "describe",
+						Usage:       "describe [tag]",
+						Description: "List the tags with their metadata, or update the metadata of a tag given the flags",
+						Args:        true,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "description",
+								Usage: "What the tag is about",
+							},
+							&cli.StringSliceFlag{
+								Name:  "synonym",
+								Usage: "Other name of the tag, can be repeated, replaces the synonyms",
+							},
+							&cli.StringSliceFlag{
+								Name:  "example",
+								Usage: "Topic or title the tag fits, can be repeated, replaces the examples",
+							},
+							&cli.StringFlag{
+								Name:  "never-use-when",
+								Usage: "When the tag must not be used",
+							},
+							&cli.BoolFlag{
+								Name:  "clear",
+								Usage: "Remove the metadata of the tag",
+							},
+						},
+						Action: DescribeTags,
+					},
+					{
+						Name:        "train",
+						Description: "Compute the tag centroids used by pages tag --tagger embeddings from the tagged pages",
+						Action:      TrainTags,
+					},
+				},
+			},
+			{
+				Name: "prompt",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "render",
+						Description: "Render the prompt tagging a page without sending it. Nothing is sent to the LLM: with --strategy summarize, long pages are shown by their head and tail instead of a summary",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "page_id",
+								Usage:    "Page ID to render the prompt for",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "strategy",
+								Usage: "How to shorten pages too long for the model: " + strings.Join(llm.ContentStrategies, ", "),
+								Value: llm.StrategyTruncate,
+							},
+							&cli.IntFlag{
+								Name:  "examples",
+								Usage: "Number of pages already tagged shown to the model as examples, 0 for none",
+							},
+						},
+						Action: RenderPrompt,
+					},
 				},
 			},
 			{
@@ -91,71 +269,490 @@ func main() {
 		},
 	}
 
-	pkg.SetupLogging()
 	err := e.Run(os.Args)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 }
 
 // ListTags lists all the tags in a given database.
 func ListTags(context *cli.Context) error {
-	tags, err := client.ListTagsForDatabaseColumn(DatabaseID, "Tags")
+	tags, err := svc.AvailableTags()
 	if err != nil {
-		return fmt.Errorf("failed to list tags for column: %w", err)
+		return err
 	}
-	for _, tag := range tags {
-		fmt.Println(tag)
+	details := make([]myNotion.TagDetail, len(tags))
+	for i, tag := range tags {
+		details[i] = myNotion.TagDetail{Name: tag}
 	}
-	return nil
+	return svc.Render(details)
+}
+
+// DescribeTags lists the tags of the database with their metadata, or updates the metadata of one tag.
+func DescribeTags(context *cli.Context) error {
+	cfg, err := svc.Config()
+	if err != nil {
+		return err
+	}
+	availableTags, err := svc.AvailableTags()
+	if err != nil {
+		return err
+	}
+	path := cfg.TagsPath(svc.configPath)
+	meta, err := pkg.LoadTagMetadata(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range meta.Unknown(availableTags) {
+		fmt.Fprintf(context.App.ErrWriter, "%s describes %q, which is not a tag of the database\n", path, name)
+	}
+
+	if context.NArg() == 0 {
+		return svc.Render(meta.Describe(availableTags))
+	}
+	tag := context.Args().First()
+	i := slices.IndexFunc(availableTags, func(name string) bool { return strings.EqualFold(name, tag) })
+	if i < 0 {
+		return fmt.Errorf("%q is not a tag of the database", tag)
+	}
+	tag = availableTags[i]
+
+	flags := []string{"description", "synonym", "example", "never-use-when", "clear"}
+	if slices.ContainsFunc(flags, context.IsSet) {
+		info := meta.Describe([]string{tag})[0]
+		for name := range meta {
+			if strings.EqualFold(name, tag) {
+				delete(meta, name)
+			}
+		}
+		if context.IsSet("description") {
+			info.Description = context.String("description")
+		}
+		if context.IsSet("synonym") {
+			info.Synonyms = context.StringSlice("synonym")
+		}
+		if context.IsSet("example") {
+			info.Examples = context.StringSlice("example")
+		}
+		if context.IsSet("never-use-when") {
+			info.NeverUseWhen = context.String("never-use-when")
+		}
+		if !context.Bool("clear") {
+			meta[tag] = info
+		}
+		if err := pkg.SaveTagMetadata(path, meta); err != nil {
+			return err
+		}
+	}
+	return svc.Render(meta.Describe([]string{tag}))
+}
+
+// TrainTags computes the tag centroids from the embeddings of the tagged pages and caches them.
+func TrainTags(context *cli.Context) error {
+	cfg, err := svc.Config()
+	if err != nil {
+		return err
+	}
+	client, err := svc.LLMClient()
+	if err != nil {
+		return err
+	}
+	dbID, err := svc.DatabaseID()
+	if err != nil {
+		return err
+	}
+	stderr := context.App.ErrWriter
+	centroids, err := client.TrainCentroids(dbID, func(done int) {
+		fmt.Fprintf(stderr, "Embedded %d pages\n", done)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to train tag centroids: %w", err)
+	}
+	path := cfg.CentroidsPath(svc.configPath)
+	if err := pkg.SaveCentroids(path, centroids); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Saved the centroids of %d tags to %s\n", len(centroids.Tags), path)
+
+	type tagCentroid struct {
+		Tag   string `json:"tag"`
+		Pages int    `json:"pages"`
+	}
+	rows := make([]tagCentroid, 0, len(centroids.Tags))
+	for tag, centroid := range centroids.Tags {
+		rows = append(rows, tagCentroid{Tag: tag, Pages: centroid.Pages})
+	}
+	slices.SortFunc(rows, func(a, b tagCentroid) int { return strings.Compare(a.Tag, b.Tag) })
+	return svc.Render(rows)
 }
 
 // QueryDatabase queries the database for pages and tags.
 func QueryDatabase(context *cli.Context) error {
+	client, err := svc.NotionClient()
+	if err != nil {
+		return err
+	}
 	dbs, err := client.ListDatabases(context.Args().First())
 	if err != nil {
 		return fmt.Errorf("failed to query databases: %w", err)
 	}
-	for _, db := range dbs {
-		// Print the parent of the database
-		parent := db.Parent
-		switch db.Parent.Type {
-		case notion.ParentTypeDatabase:
-			fmt.Printf("Database(%s) -> %s: %s\n", parent.DatabaseID, db.Title[0].PlainText, db.ID)
-		case notion.ParentTypePage:
-			fmt.Printf("Page(%s) -> %s: %s\n", parent.PageID, db.Title[0].PlainText, db.ID)
-		case notion.ParentTypeWorkspace:
-			fmt.Printf("Workspace -> %s: %s\n", db.Title[0].PlainText, db.ID)
-		default:
-			fmt.Println("Unknown parent type")
-		}
+	details := make([]myNotion.DatabaseDetail, len(dbs))
+	for i, db := range dbs {
+		details[i] = myNotion.NewDatabaseDetail(db)
 	}
-	return nil
+	return svc.Render(details)
 }
 
-// QueryPages queries pages in the database, conditionally filtering if tagged or untagged.
+// QueryPages queries pages in the database, filtering by their tags or a filter expression.
 func QueryPages(context *cli.Context) error {
-	pageDetails, err := client.FetchPages(DatabaseID, context.Bool("untagged"))
+	client, err := svc.NotionClient()
+	if err != nil {
+		return err
+	}
+	dbID, err := svc.DatabaseID()
+	if err != nil {
+		return err
+	}
+	query, err := pageQuery(context, client, dbID)
+	if err != nil {
+		return err
+	}
+	pageDetails, err := client.FetchPages(dbID, query)
 	if err != nil {
 		return fmt.Errorf("failed to query pages: %w", err)
 	}
-	myNotion.PrintPageDetails(pageDetails)
-	return nil
+	return svc.Render(pageDetails)
+}
+
+// pageQuery builds the database query from the tag, --where and --sort flags.
+func pageQuery(context *cli.Context, client *pkg.Client, dbID string) (notion.DatabaseQuery, error) {
+	var query notion.DatabaseQuery
+	tagFilter, err := pkg.TagQuery{
+		Untagged: context.Bool("untagged") || context.Bool("all-untagged"),
+		Tagged:   context.Bool("tagged"),
+		Tags:     context.StringSlice("tag"),
+	}.Filter(pkg.TagColumn)
+	if err != nil {
+		return query, err
+	}
+	query.Filter = tagFilter
+
+	where, sorts := context.String("where"), context.StringSlice("sort")
+	if where == "" && len(sorts) == 0 {
+		return query, nil
+	}
+	schema, err := client.DatabaseSchema(dbID)
+	if err != nil {
+		return query, err
+	}
+	if where != "" {
+		whereFilter, err := pkg.ParseWhere(where, schema)
+		if err != nil {
+			return query, fmt.Errorf("invalid --where: %w", err)
+		}
+		query.Filter = pkg.And(tagFilter, whereFilter)
+		if err := pkg.CheckFilterDepth(query.Filter); err != nil {
+			return query, fmt.Errorf("invalid --where combined with the tag flags: %w", err)
+		}
+	}
+	if query.Sorts, err = pkg.ParseSorts(sorts, schema); err != nil {
+		return query, fmt.Errorf("invalid --sort: %w", err)
+	}
+	return query, nil
 }
 
 // TagPages tags pages in the database with a tag generated by an LLM.
 func TagPages(context *cli.Context) error {
-	errs := make([]error, 0)
+	client, err := svc.LLMClient()
+	if err != nil {
+		return err
+	}
+	availableTags, err := svc.AvailableTags()
+	if err != nil {
+		return err
+	}
+	if client.ContentStrategy, err = llm.ParseContentStrategy(context.String("strategy")); err != nil {
+		return err
+	}
+	if err := useTagger(context.String("tagger"), client); err != nil {
+		return err
+	}
+	// Examples are only shown to the LLM, other taggers don't need them loaded.
+	if client.Tagger != nil {
+		if context.Int("examples") > 0 {
+			return fmt.Errorf("--examples only applies to the %s tagger", pkg.TaggerLLM)
+		}
+	} else if err := loadExamples(context, client); err != nil {
+		return err
+	}
 
-	for _, id := range context.StringSlice("page_id") {
-		err := client.TagPage(id, availableTags)
+	ids := context.StringSlice("page_id")
+	if context.Bool("all-untagged") || context.String("where") != "" {
+		dbID, err := svc.DatabaseID()
+		if err != nil {
+			return err
+		}
+		query, err := pageQuery(context, client, dbID)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to tag page %s: %w", id, err))
+			return err
+		}
+		pages, err := client.FetchPages(dbID, query)
+		if err != nil {
+			return fmt.Errorf("failed to query pages: %w", err)
+		}
+		for _, page := range pages {
+			ids = append(ids, page.ID)
 		}
 	}
-	if len(errs) != 0 {
-		// return all the errors wrapped in an error:
-		return fmt.Errorf("%v", errs)
+	if len(ids) == 0 {
+		return fmt.Errorf("no pages to tag: use --page_id, --all-untagged or --where")
+	}
+
+	client.DryRun = context.Bool("dry-run")
+	stderr := context.App.ErrWriter
+	results := client.TagPages(ids, availableTags, context.Int("workers"), func(done int, result pkg.TagResult) {
+		if client.DryRun && result.Err == nil {
+			fmt.Fprintf(stderr, "[%d/%d] %s %s: %s\n", done, len(ids), result.PageID, result.Name, tagDiff(result))
+			return
+		}
+		if result.Review {
+			fmt.Fprintf(stderr, "[%d/%d] %s needs review: %v\n", done, len(ids), result.PageID, result.Err)
+			return
+		}
+		if result.Err != nil {
+			fmt.Fprintf(stderr, "[%d/%d] %s failed: %v\n", done, len(ids), result.PageID, result.Err)
+			return
+		}
+		if len(result.Rejected) > 0 {
+			fmt.Fprintf(stderr, "[%d/%d] %s tagged: %s, rejected: %s\n", done, len(ids), result.PageID, strings.Join(result.Tags, ", "), strings.Join(result.Rejected, ", "))
+			return
+		}
+		fmt.Fprintf(stderr, "[%d/%d] %s tagged: %s\n", done, len(ids), result.PageID, strings.Join(result.Tags, ", "))
+	})
+	if err := svc.Render(results); err != nil {
+		return err
+	}
+
+	summary := pkg.SummarizeTagResults(results)
+	verb := "Tagged"
+	if client.DryRun {
+		verb = "Planned tags for"
+	}
+	fmt.Fprintf(stderr, "%s %d of %d pages, %d failed, %d need review\n", verb, summary.Tagged, summary.Total, summary.Failed, summary.Review)
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d pages failed to tag", summary.Failed, summary.Total)
+	}
+	return nil
+}
+
+// useTagger sets up the tagger named by the --tagger flag.
+func useTagger(name string, client *pkg.Client) error {
+	switch name {
+	case "", pkg.TaggerLLM:
+		client.Tagger = nil
+		return nil
+	case pkg.TaggerEmbeddings:
+		cfg, err := svc.Config()
+		if err != nil {
+			return err
+		}
+		dbID, err := svc.DatabaseID()
+		if err != nil {
+			return err
+		}
+		centroids, err := pkg.LoadCentroids(cfg.CentroidsPath(svc.configPath))
+		if err != nil {
+			return err
+		}
+		tagger, err := pkg.NewEmbeddingTagger(client, centroids, dbID, cfg.Embeddings)
+		if err != nil {
+			return err
+		}
+		client.Tagger = tagger
+		return nil
+	default:
+		return fmt.Errorf("unknown tagger %q, use one of %s", name, strings.Join(pkg.Taggers, ", "))
+	}
+}
+
+// loadExamples loads the example pages when the --examples flag or the config asks for them.
+func loadExamples(context *cli.Context, client *pkg.Client) error {
+	if context.IsSet("examples") {
+		client.Examples.Count = context.Int("examples")
+	}
+	if client.Examples.Count <= 0 {
+		return nil
+	}
+	dbID, err := svc.DatabaseID()
+	if err != nil {
+		return err
+	}
+	return client.LoadExamples(dbID)
+}
+
+// RenderPrompt prints the prompt that would tag a page, with the configured templates and model.
+func RenderPrompt(context *cli.Context) error {
+	client, err := svc.LLMClient()
+	if err != nil {
+		return err
+	}
+	availableTags, err := svc.AvailableTags()
+	if err != nil {
+		return err
+	}
+	if client.ContentStrategy, err = llm.ParseContentStrategy(context.String("strategy")); err != nil {
+		return err
+	}
+	if err := loadExamples(context, client); err != nil {
+		return err
+	}
+	prompt, err := client.RenderPrompt(context.String("page_id"), availableTags)
+	if err != nil {
+		return err
+	}
+	if !svc.Table() {
+		return svc.Render([]pkg.RenderedPrompt{prompt})
+	}
+	out := context.App.Writer
+	fmt.Fprintf(out, "--- system ---\n%s\n\n", prompt.System)
+	for i, example := range prompt.Examples {
+		fmt.Fprintf(out, "--- example %d ---\n%s\n\n", i+1, example)
+	}
+	fmt.Fprintf(out, "--- user ---\n%s\n\n--- tools ---\n%s\n", prompt.User, prompt.Tools)
+	if prompt.Note != "" {
+		fmt.Fprintf(context.App.ErrWriter, "Note: %s\n", prompt.Note)
+	}
+	return nil
+}
+
+// SearchPages ranks the pages in the database by similarity in meaning to the query.
+func SearchPages(context *cli.Context) error {
+	query := strings.Join(context.Args().Slice(), " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("no query: use pages search <query>")
+	}
+	cfg, err := svc.Config()
+	if err != nil {
+		return err
+	}
+	client, err := svc.LLMClient()
+	if err != nil {
+		return err
+	}
+	dbID, err := svc.DatabaseID()
+	if err != nil {
+		return err
+	}
+
+	path := cfg.IndexPath(svc.configPath)
+	var index *pkg.SearchIndex
+	if context.Bool("no-update") {
+		index, err = pkg.LoadIndex(path, dbID, client.EmbeddingModel)
+	} else {
+		stderr := context.App.ErrWriter
+		var result pkg.IndexResult
+		index, result, err = client.IndexPages(dbID, path, func(done int) {
+			fmt.Fprintf(stderr, "Indexed %d pages\n", done)
+		})
+		if err == nil {
+			fmt.Fprintf(stderr, "Indexed %d pages, %d unchanged, %d removed\n", result.Indexed, result.Unchanged, result.Removed)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+
+	results, err := client.SearchPages(index, query, context.Int("limit"))
+	if err != nil {
+		return err
+	}
+	return svc.Render(results)
+}
+
+// tagDiff describes the change of the tags of a page, as "a, b → a, c (+c -b)".
+func tagDiff(result pkg.TagResult) string {
+	diff := fmt.Sprintf("%s → %s", tagList(result.Current), tagList(result.Tags))
+	if len(result.Added) == 0 && len(result.Removed) == 0 {
+		return diff + " (unchanged)"
+	}
+	var changes []string
+	for _, tag := range result.Added {
+		changes = append(changes, "+"+tag)
+	}
+	for _, tag := range result.Removed {
+		changes = append(changes, "-"+tag)
+	}
+	return diff + " (" + strings.Join(changes, " ") + ")"
+}
+
+func tagList(tags []string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	return strings.Join(tags, ", ")
+}
+
+// ApplyPlan writes the tags of a plan made by a dry run of pages tag.
+func ApplyPlan(context *cli.Context) error {
+	if context.NArg() != 1 {
+		return fmt.Errorf("no plan: use pages apply <plan.json>")
+	}
+	plan, err := pkg.LoadPlan(context.Args().First())
+	if err != nil {
+		return err
+	}
+	client, err := svc.NotionClient()
+	if err != nil {
+		return err
+	}
+	availableTags, err := svc.AvailableTags()
+	if err != nil {
+		return err
+	}
+
+	stderr := context.App.ErrWriter
+	results := client.ApplyPlan(plan, availableTags, context.Bool("force"), func(done int, result pkg.ApplyResult) {
+		if result.Error != "" {
+			fmt.Fprintf(stderr, "[%d/%d] %s %s: %s\n", done, len(plan), result.PageID, result.Status, result.Error)
+			return
+		}
+		fmt.Fprintf(stderr, "[%d/%d] %s %s: %s\n", done, len(plan), result.PageID, result.Status, strings.Join(result.Tags, ", "))
+	})
+	if err := svc.Render(results); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	fmt.Fprintf(stderr, "Applied %d of %d pages, %d unchanged, %d skipped, %d conflicts, %d failed\n",
+		counts[pkg.ApplyApplied], len(results), counts[pkg.ApplyUnchanged], counts[pkg.ApplySkipped], counts[pkg.ApplyConflict], counts[pkg.ApplyFailed])
+	if failed := counts[pkg.ApplyConflict] + counts[pkg.ApplyFailed]; failed > 0 {
+		return fmt.Errorf("%d of %d pages failed to apply", failed, len(results))
+	}
+	return nil
+}
+
+// ExportPages writes the pages in the database to Markdown files.
+func ExportPages(context *cli.Context) error {
+	client, err := svc.NotionClient()
+	if err != nil {
+		return err
+	}
+	dbID, err := svc.DatabaseID()
+	if err != nil {
+		return err
+	}
+	result, err := client.ExportPages(dbID, pkg.ExportOptions{
+		Dir:      context.String("dir"),
+		Filename: context.String("filename"),
+		Force:    context.Bool("force"),
+	})
+	fmt.Fprintf(context.App.ErrWriter, "Exported %d pages, %d unchanged\n", result.Written, result.Skipped)
+	if err != nil {
+		return fmt.Errorf("failed to export pages: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,47 @@
+// Package backoff holds the retry timing shared by the Notion and LLM clients.
+package backoff
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Delay returns the exponential delay before retry attempt, counted from 0: base doubled
+// on every attempt and capped at limit, with jitter over its upper half.
+func Delay(base, limit time.Duration, attempt int) time.Duration {
+	delay := base << attempt
+	if delay <= 0 || delay > limit {
+		delay = limit
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Sleep waits for d, returning early with the context's error when ctx is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ParseRetryAfter parses a Retry-After header value, given either in seconds or as
+// an HTTP date relative to now. It reports false when value is empty or invalid.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
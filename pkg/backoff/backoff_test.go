@@ -0,0 +1,50 @@
+package backoff_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/klauern/notion-table-reader/pkg/backoff"
+	. "github.com/onsi/gomega"
+)
+
+func TestDelay(t *testing.T) {
+	RegisterTestingT(t)
+	for attempt := 0; attempt < 10; attempt++ {
+		want := min(100*time.Millisecond<<attempt, time.Second)
+		Expect(backoff.Delay(100*time.Millisecond, time.Second, attempt)).To(And(
+			BeNumerically(">=", want/2),
+			BeNumerically("<=", want),
+		))
+	}
+	Expect(backoff.Delay(time.Second, time.Minute, 100)).To(BeNumerically("<=", time.Minute))
+}
+
+func TestSleep(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(backoff.Sleep(context.Background(), time.Millisecond)).To(Succeed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Expect(backoff.Sleep(ctx, time.Hour)).To(MatchError(context.Canceled))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	RegisterTestingT(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	delay, ok := backoff.ParseRetryAfter("3", now)
+	Expect(ok).To(BeTrue())
+	Expect(delay).To(Equal(3 * time.Second))
+
+	delay, ok = backoff.ParseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	Expect(ok).To(BeTrue())
+	Expect(delay).To(Equal(time.Minute))
+
+	_, ok = backoff.ParseRetryAfter("", now)
+	Expect(ok).To(BeFalse())
+	_, ok = backoff.ParseRetryAfter("soon", now)
+	Expect(ok).To(BeFalse())
+}
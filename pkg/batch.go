@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"sync"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+)
+
+// DefaultTagWorkers is the number of pages tagged concurrently when no worker count is given.
+const DefaultTagWorkers = 4
+
+// TagResult is the outcome of tagging a single page. The results of a dry run are a plan,
+// applied with ApplyPlan.
+type TagResult struct {
+	PageID string `json:"page_id"`
+	Name   string `json:"name,omitempty"`
+	// Current are the tags of the page before tagging.
+	Current []string `json:"current"`
+	// Tags are the tags written to the page, or proposed in a dry run.
+	Tags []string `json:"tags"`
+	// Added and Removed are the differences between Current and Tags.
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	// Rejected are the suggested tags that aren't in the vocabulary.
+	Rejected []string `json:"rejected,omitempty"`
+	// Confidence and Rationale are reported by models supporting structured output.
+	Confidence float64 `json:"confidence,omitempty"`
+	Rationale  string  `json:"rationale,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	// Review is set when the LLM refused or filtered the page, so it needs tagging by hand.
+	Review bool  `json:"review,omitempty"`
+	Err    error `json:"-"`
+}
+
+// TagSummary aggregates the results of a batch of pages.
+type TagSummary struct {
+	Total  int
+	Tagged int
+	Failed int
+	// Review counts the failed pages that need tagging by hand.
+	Review int
+}
+
+// TagPages tags pages with a bounded pool of workers. progress, if set, is called
+// from a single goroutine as each page finishes, with the number of pages done so far.
+// The results are returned in the order of ids.
+func (l *Client) TagPages(ids []string, availableTags []string, workers int, progress func(done int, result TagResult)) []TagResult {
+	if workers <= 0 {
+		workers = DefaultTagWorkers
+	}
+
+	type indexed struct {
+		i      int
+		result TagResult
+	}
+	jobs := make(chan int)
+	finished := make(chan indexed)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(ids)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := l.tagPage(ids[i], availableTags)
+				result.Err = err
+				if result.Err != nil {
+					result.Error = result.Err.Error()
+					result.Review = llm.NeedsReview(result.Err)
+				}
+				finished <- indexed{i, result}
+			}
+		}()
+	}
+	go func() {
+		for i := range ids {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(finished)
+	}()
+
+	results := make([]TagResult, len(ids))
+	done := 0
+	for f := range finished {
+		results[f.i] = f.result
+		done++
+		if progress != nil {
+			progress(done, f.result)
+		}
+	}
+	return results
+}
+
+// SummarizeTagResults counts the tagged and failed pages in results.
+func SummarizeTagResults(results []TagResult) TagSummary {
+	summary := TagSummary{Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			summary.Failed++
+			if r.Review {
+				summary.Review++
+			}
+		} else {
+			summary.Tagged++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,100 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTagPages(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockLLMClient := mocks.NewMockOpenAIClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.LLMClient = mockLLMClient
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		if id == "missing" {
+			return notion.Page{}, errors.New("not found")
+		}
+		return notion.Page{ID: id, Properties: notion.DatabasePageProperties{
+			"Name": notion.DatabasePageProperty{Title: []notion.RichText{{PlainText: id}}},
+		}}, nil
+	}).Times(3)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(notion.BlockChildrenResponse{}, nil).Times(2)
+	mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "go"}}},
+	}, nil).Times(2)
+	mockNotionClient.EXPECT().UpdatePage(gomock.Any(), gomock.Any(), gomock.Any()).Return(notion.Page{}, nil).Times(2)
+
+	var mu sync.Mutex
+	var progress []int
+	results := client.TagPages([]string{"page-1", "missing", "page-2"}, []string{"go"}, 2, func(done int, _ pkg.TagResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, done)
+	})
+
+	Expect(progress).To(Equal([]int{1, 2, 3}))
+	Expect(results).To(HaveLen(3))
+	Expect(results[0]).To(Equal(pkg.TagResult{PageID: "page-1", Name: "page-1", Tags: []string{"go"}, Added: []string{"go"}}))
+	Expect(results[1].PageID).To(Equal("missing"))
+	Expect(results[1].Err).To(HaveOccurred())
+	Expect(results[1].Error).To(ContainSubstring("not found"))
+	Expect(results[2]).To(Equal(pkg.TagResult{PageID: "page-2", Name: "page-2", Tags: []string{"go"}, Added: []string{"go"}}))
+
+	Expect(pkg.SummarizeTagResults(results)).To(Equal(pkg.TagSummary{Total: 3, Tagged: 2, Failed: 1}))
+}
+
+func TestTagPages_UnusableResponses(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockLLMClient := mocks.NewMockOpenAIClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.LLMClient = mockLLMClient
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		return notion.Page{ID: id, Properties: notion.DatabasePageProperties{
+			"Name": notion.DatabasePageProperty{Title: []notion.RichText{{PlainText: id}}},
+		}}, nil
+	}).Times(2)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(notion.BlockChildrenResponse{
+		Results: []notion.Block{&notion.ParagraphBlock{RichText: []notion.RichText{{PlainText: "A long article about Go."}}}},
+	}, nil).Times(2)
+	gomock.InOrder(
+		mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{FinishReason: openai.FinishReasonContentFilter}},
+		}, nil),
+		mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "go\nno"}, FinishReason: openai.FinishReasonLength}},
+		}, nil),
+		mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "go"}, FinishReason: openai.FinishReasonStop}},
+		}, nil),
+	)
+	mockNotionClient.EXPECT().UpdatePage(gomock.Any(), "truncated", gomock.Any()).Return(notion.Page{}, nil)
+
+	results := client.TagPages([]string{"filtered", "truncated"}, []string{"go"}, 1, nil)
+
+	Expect(results[0].Review).To(BeTrue())
+	Expect(results[0].Err).To(MatchError(llm.ErrContentFiltered))
+	Expect(results[1]).To(Equal(pkg.TagResult{PageID: "truncated", Name: "truncated", Tags: []string{"go"}, Added: []string{"go"}}))
+	Expect(pkg.SummarizeTagResults(results)).To(Equal(pkg.TagSummary{Total: 2, Tagged: 1, Failed: 1, Review: 1}))
+}
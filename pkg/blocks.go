@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dstotijn/go-notion"
+	readNotion "github.com/klauern/notion-table-reader/pkg/notion"
+)
+
+const (
+	// DefaultMaxBlockDepth is the number of nesting levels fetched when BlockTreeOptions.MaxDepth is unset.
+	DefaultMaxBlockDepth = 5
+	// DefaultBlockConcurrency is the number of concurrent requests made when BlockTreeOptions.Concurrency is unset.
+	DefaultBlockConcurrency = 3
+)
+
+// BlockTreeOptions controls how nested block children are fetched.
+type BlockTreeOptions struct {
+	// MaxDepth is the number of nesting levels to fetch, 1 fetches only top-level blocks.
+	MaxDepth int `yaml:"max_depth"`
+	// Concurrency is the maximum number of requests in flight at once.
+	Concurrency int `yaml:"concurrency"`
+}
+
+// FetchBlockTree fetches the children of a block or page, recursing into blocks
+// that have children up to the configured depth.
+func (l *Client) FetchBlockTree(blockId string) ([]readNotion.BlockNode, error) {
+	maxDepth := l.BlockTree.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxBlockDepth
+	}
+	concurrency := l.BlockTree.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBlockConcurrency
+	}
+
+	f := &treeFetcher{
+		client:   l,
+		maxDepth: maxDepth,
+		sem:      make(chan struct{}, concurrency),
+	}
+	return f.fetch(blockId, 1)
+}
+
+type treeFetcher struct {
+	client   *Client
+	maxDepth int
+	sem      chan struct{}
+}
+
+func (f *treeFetcher) fetch(blockId string, depth int) ([]readNotion.BlockNode, error) {
+	f.sem <- struct{}{}
+	blocks, err := f.client.IterateBlockChildren(blockId).Collect()
+	<-f.sem
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch children of block %s: %w", blockId, err)
+	}
+
+	nodes := readNotion.NewBlockNodes(blocks...)
+	if depth >= f.maxDepth {
+		return nodes, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(nodes))
+	for i, block := range blocks {
+		childrenID, ok := childrenSource(block)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			nodes[i].Children, errs[i] = f.fetch(id, depth+1)
+		}(i, childrenID)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// childrenSource returns the ID of the block holding the children of block.
+// Child pages and databases are separate documents and are not descended into,
+// and synced block references read their children from the original block.
+func childrenSource(block notion.Block) (string, bool) {
+	switch b := block.(type) {
+	case *notion.ChildPageBlock, *notion.ChildDatabaseBlock:
+		return "", false
+	case *notion.SyncedBlock:
+		if b.SyncedFrom != nil && b.SyncedFrom.BlockID != "" {
+			return b.SyncedFrom.BlockID, true
+		}
+	}
+	return block.ID(), block.HasChildren()
+}
@@ -0,0 +1,93 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func blockChildren(t *testing.T, raw string) notion.BlockChildrenResponse {
+	var resp notion.BlockChildrenResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestFetchBlockTree(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "page", gomock.Any()).Return(blockChildren(t, `{"results": [
+		{"id": "toggle", "type": "toggle", "has_children": true, "toggle": {"rich_text": [{"plain_text": "Toggle"}]}},
+		{"id": "child-page", "type": "child_page", "has_children": true, "child_page": {"title": "Sub page"}}
+	]}`), nil)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "toggle", gomock.Any()).Return(blockChildren(t, `{"results": [
+		{"id": "item", "type": "bulleted_list_item", "has_children": true, "bulleted_list_item": {"rich_text": [{"plain_text": "Item"}]}}
+	]}`), nil)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "item", gomock.Any()).Return(blockChildren(t, `{"results": [
+		{"id": "nested", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": "Nested"}]}}
+	]}`), nil)
+
+	nodes, err := client.FetchBlockTree("page")
+	Expect(err).To(BeNil())
+	Expect(nodes).To(HaveLen(2))
+	Expect(nodes[0].Children).To(HaveLen(1))
+	Expect(nodes[0].Children[0].Children).To(HaveLen(1))
+	Expect(nodes[1].Children).To(BeEmpty())
+
+	page := myNotion.PageWithBlocks{Blocks: nodes}
+	Expect(page.NormalizeBody()).To(Equal("- Toggle\n  - Item\n\n    Nested\n\n[Sub page](https://www.notion.so/childpage)"))
+}
+
+func TestFetchBlockTree_MaxDepth(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.BlockTree = pkg.BlockTreeOptions{MaxDepth: 1}
+
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "page", gomock.Any()).Return(blockChildren(t, `{"results": [
+		{"id": "toggle", "type": "toggle", "has_children": true, "toggle": {"rich_text": [{"plain_text": "Toggle"}]}}
+	]}`), nil)
+
+	nodes, err := client.FetchBlockTree("page")
+	Expect(err).To(BeNil())
+	Expect(nodes).To(HaveLen(1))
+	Expect(nodes[0].Children).To(BeEmpty())
+}
+
+func TestFetchBlockTree_IgnoresLimit(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.Pagination = pkg.Pagination{PageSize: 50, Limit: 1}
+
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "page", &notion.PaginationQuery{PageSize: 50}).Return(blockChildren(t, `{"results": [
+		{"id": "first", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": "First"}]}},
+		{"id": "second", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": "Second"}]}}
+	]}`), nil)
+
+	nodes, err := client.FetchBlockTree("page")
+	Expect(err).To(BeNil())
+	Expect(nodes).To(HaveLen(2))
+}
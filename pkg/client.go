@@ -2,10 +2,15 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/dstotijn/go-notion"
 	"github.com/klauern/notion-table-reader/pkg/llm"
@@ -14,15 +19,39 @@ import (
 )
 
 type Client struct {
-	LLMClient    llm.OpenAIClient
-	context      context.Context
-	Model        string
-	MaxTokens    int
-	NotionClient notionTypes.NotionClient
-}
+	LLMClient llm.OpenAIClient
+	context   context.Context
+	Model     string
+	// MaxTokens is the most tokens the model may answer with.
+	MaxTokens int
+	// ContextWindow is the number of tokens of the prompt and the answer together.
+	ContextWindow int
+	NotionClient  notionTypes.NotionClient
+	Pagination    Pagination
+	BlockTree     BlockTreeOptions
+	// Retry is the retry policy shared by all LLM requests.
+	Retry llm.RetryPolicy
+	// Prompts are the prompt templates, the built-in ones when nil.
+	Prompts *llm.Prompts
+	// TagMetadata describes the tags in the prompt, and maps their synonyms back to them.
+	TagMetadata llm.TagMetadata
+	// Examples controls the few-shot examples, loaded with LoadExamples.
+	Examples ExampleOptions
+	// Embedder creates embeddings with EmbeddingModel, for the embeddings tagger and search.
+	Embedder       llm.EmbeddingClient
+	EmbeddingModel string
+	// DryRun suggests tags in TagPages without writing them to Notion.
+	DryRun bool
+	// Tagger identifies the tags of pages in TagPage and TagPages instead of the LLM when set.
+	Tagger Tagger
+	// ContentStrategy is how page content too long for the prompt is shortened, one of llm.ContentStrategies.
+	ContentStrategy string
 
-var tokenMax map[string]int = map[string]int{
-	openai.GPT4o: 4096,
+	rateLimits       *llm.RateLimits
+	toolsUnsupported atomic.Bool
+	examplePool      []llm.Example
+	// exampleContent caches the content of example pages by ID.
+	exampleContent sync.Map
 }
 
 // NewClient creates a new client for the given API keys and returns a *Client.
@@ -34,85 +63,265 @@ func NewClient(ctx context.Context, openai_key string, notion_api_key string) *C
 		notion_api_key = os.Getenv("NOTION_API_KEY")
 	}
 	config := openai.DefaultConfig(openai_key)
-	model := openai.GPT4TurboPreview
-	maxToken := tokenMax[model]
+	rateLimits := &llm.RateLimits{}
+	config.HTTPClient = &http.Client{Transport: rateLimits.Transport(nil)}
+	model := llm.DefaultModel(llm.ProviderOpenAI)
+	info, _ := llm.LookupModel(model, nil)
+	openaiClient := openai.NewClientWithConfig(config)
 	return &Client{
-		context:      ctx,
-		LLMClient:    openai.NewClientWithConfig(config),
-		NotionClient: notion.NewClient(notion_api_key),
-		Model:        model,
-		MaxTokens:    maxToken,
+		context:        ctx,
+		LLMClient:      openaiClient,
+		NotionClient:   notionTypes.NewNotionClient(notion_api_key, notionTypes.RetryOptions{}),
+		Model:          model,
+		MaxTokens:      info.MaxOutputTokens,
+		ContextWindow:  info.ContextWindow,
+		Embedder:       openaiClient,
+		EmbeddingModel: llm.DefaultEmbeddingModel(llm.ProviderOpenAI),
+		rateLimits:     rateLimits,
 	}
 }
 
-// RequestChatCompletion returns a chat completion response.
-func (l *Client) RequestChatCompletion(messages []openai.ChatCompletionMessage) (string, error) {
-	var resp openai.ChatCompletionResponse
-	var err error
-
-	retries := 3
-	for i := 0; i < retries; i++ {
-		resp, err = l.LLMClient.CreateChatCompletion(l.context, openai.ChatCompletionRequest{
-			Model:     l.Model,
-			Messages:  messages,
-			MaxTokens: l.MaxTokens,
-		})
-		if err == nil {
-			break
-		}
-		slog.Error("Getting chat completion", "resp", err)
+// UseModel switches to the named model, taking its token limits from custom or the built-in models.
+func (l *Client) UseModel(name string, custom map[string]llm.ModelInfo) error {
+	info, err := llm.LookupModel(name, custom)
+	if err != nil {
+		return err
+	}
+	l.Model, l.MaxTokens, l.ContextWindow = name, info.MaxOutputTokens, info.ContextWindow
+	return nil
+}
 
-		fmt.Printf("Error creating chat completion request (attempt %d): %v\n", i+1, err)
-		if i < retries-1 {
-			fmt.Println("Retrying...")
-		}
+// promptBudget is how many tokens the user message may use next to the system prompt,
+// the tools and the answer.
+func (l *Client) promptBudget(tok llm.Tokenizer, system string, tools []openai.Tool) int {
+	if l.ContextWindow <= l.MaxTokens {
+		return l.MaxTokens
 	}
+	budget := l.ContextWindow - l.MaxTokens - tok.Count(system) - 2*llm.MessageOverhead
+	if len(tools) > 0 {
+		schema, _ := json.Marshal(tools)
+		budget -= tok.Count(string(schema))
+	}
+	return budget
+}
 
+// UseProvider replaces the LLM client with one for the configured provider.
+func (l *Client) UseProvider(cfg llm.ProviderConfig) error {
+	if l.rateLimits == nil {
+		l.rateLimits = &llm.RateLimits{}
+	}
+	provider, err := llm.NewProvider(cfg, &http.Client{Transport: l.rateLimits.Transport(nil)})
+	if err != nil {
+		return err
+	}
+	l.LLMClient = provider
+	l.Embedder, _ = provider.(llm.EmbeddingClient)
+	l.EmbeddingModel = llm.DefaultEmbeddingModel(cfg.WithDefaults().Provider)
+	l.toolsUnsupported.Store(false)
+	return nil
+}
+
+// RequestChatCompletion returns a chat completion response, retrying failed requests
+// according to the client's retry policy. Unusable responses yield a *llm.ResponseError.
+func (l *Client) RequestChatCompletion(messages []openai.ChatCompletionMessage) (string, error) {
+	resp, err := l.createChatCompletion(openai.ChatCompletionRequest{Messages: messages})
 	if err != nil {
-		return "", fmt.Errorf("error creating chat completion request after %d attempts: %w", retries, err)
+		return "", err
+	}
+	return llm.ChoiceContent(resp)
+}
+
+// createChatCompletion sends req with the client's model and token limit, retrying failed requests.
+func (l *Client) createChatCompletion(req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	req.Model = l.Model
+	if req.MaxTokens == 0 {
+		req.MaxTokens = l.MaxTokens
+	}
+	resp, err := llm.Retry(l.context, l.Retry, l.rateLimits, "chat completion request", func(ctx context.Context) (openai.ChatCompletionResponse, error) {
+		return l.LLMClient.CreateChatCompletion(ctx, req)
+	})
+	if err != nil {
+		slog.Error("Getting chat completion", "err", err)
+		return resp, err
 	}
 
 	slog.Debug("number of responses", "count", len(resp.Choices))
-	return resp.Choices[0].Message.Content, nil
+	return resp, nil
 }
 
+// IdentifyTags asks the LLM for the tags of the content, keeping only tags from tagOptions.
 func (l *Client) IdentifyTags(messageContent *llm.TagInput, tagOptions []string) ([]string, error) {
+	validation, err := l.identifyTags(messageContent, tagOptions)
+	return validation.Tags, err
+}
+
+// identifyTags asks the LLM for tags and matches its answer to tagOptions. It fails with
+// llm.ErrNoValidTags when the answer has no usable tag.
+func (l *Client) identifyTags(messageContent *llm.TagInput, tagOptions []string) (llm.TagValidation, error) {
+	messages, err := l.TagMessages(messageContent, tagOptions)
+	if err != nil {
+		return llm.TagValidation{}, err
+	}
+
+	suggestion, err := l.suggestTags(messages, tagOptions)
+	if err != nil {
+		return llm.TagValidation{}, err
+	}
+
+	validation := llm.ValidateTags(l.TagMetadata.ResolveSynonyms(suggestion.Tags, tagOptions), tagOptions)
+	validation.Confidence, validation.Rationale = suggestion.Confidence, suggestion.Rationale
+	if len(validation.Rejected) > 0 {
+		slog.Warn("Rejected suggested tags", "tags", strings.Join(validation.Rejected, ", "))
+	}
+	if len(validation.Tags) == 0 {
+		return validation, fmt.Errorf("%w: %q", llm.ErrNoValidTags, strings.Join(suggestion.Tags, ", "))
+	}
+	return validation, nil
+}
+
+// TagMessages renders the prompt asking for the tags of a page, fitting its content in the
+// context window next to the system prompt, the examples, the tools and the answer.
+func (l *Client) TagMessages(messageContent *llm.TagInput, tagOptions []string) ([]openai.ChatCompletionMessage, error) {
+	return l.tagMessages(messageContent, tagOptions, l.ContentStrategy)
+}
+
+func (l *Client) tagMessages(messageContent *llm.TagInput, tagOptions []string, strategy string) ([]openai.ChatCompletionMessage, error) {
+	prompts := l.prompts()
+	tok := llm.TokenizerForModel(l.Model)
+	opts := l.Examples.WithDefaults()
+	// examples take at most a quarter of the prompt, the page itself matters most
+	exampleBudget := min(opts.Tokens, (l.ContextWindow-l.MaxTokens)/4)
+	examples, shown, exampleTokens, err := prompts.ExampleMessages(l.examples(messageContent), exampleBudget, opts.ContentTokens, tok)
+	if err != nil {
+		return nil, err
+	}
+
+	data := llm.NewPromptData(tagOptions, l.TagMetadata)
+	data.Page = llm.TagInput{Title: messageContent.Title, URL: messageContent.URL, Properties: messageContent.Properties}
+	data.Examples = shown
+	system, err := prompts.RenderSystem(data)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := l.promptBudget(tok, system, []openai.Tool{llm.TagTool(tagOptions)}) - exampleTokens
+	messageContent, err = l.condenseContent(messageContent, budget, tok, strategy)
+	if err != nil {
+		return nil, err
+	}
+	content, truncation, err := prompts.FitTagInput(messageContent, budget, tok)
+	if err != nil {
+		return nil, err
+	}
+	if truncation.Dropped > 0 {
+		slog.Info("Truncated page content to fit the context window", "title", messageContent.Title,
+			"tokens", truncation.Tokens, "dropped_tokens", truncation.Dropped)
+	}
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    "system",
-			Content: llm.GenerateSystemPrompt(tagOptions),
-		},
-		{
-			Role:    "user",
-			Content: llm.GenerateTagInputMessage(messageContent, l.MaxTokens),
+			Content: system,
 		},
 	}
+	messages = append(messages, examples...)
+	return append(messages, openai.ChatCompletionMessage{
+		Role:    "user",
+		Content: content,
+	}), nil
+}
+
+func (l *Client) prompts() *llm.Prompts {
+	if l.Prompts == nil {
+		return llm.DefaultPrompts()
+	}
+	return l.Prompts
+}
 
-	response, err := l.RequestChatCompletion(messages)
+// condenseContent shortens page content that doesn't fit in budget according to the content strategy.
+// Truncation is left to llm.FitTagInput.
+func (l *Client) condenseContent(input *llm.TagInput, budget int, tok llm.Tokenizer, strategy string) (*llm.TagInput, error) {
+	limit, err := l.prompts().ContentBudget(input, budget, tok)
 	if err != nil {
 		return nil, err
 	}
+	tokens := tok.Count(input.Raw)
+	if tokens <= limit {
+		return input, nil
+	}
+
+	condensed := *input
+	switch strategy {
+	case llm.StrategyHeadTail:
+		condensed.Raw = llm.HeadTail(input.Raw, limit, tok)
+	case llm.StrategySummarize:
+		chunkSize := min(l.ContextWindow-llm.SummaryTokens-tok.Count(llm.SummaryPrompt)-2*llm.MessageOverhead, llm.MaxChunkTokens)
+		summary, err := llm.Summarize(input.Raw, limit, max(chunkSize, llm.SummaryTokens), tok, l.summarizeChunk)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Summarized page content", "title", input.Title, "tokens", tokens, "summary_tokens", tok.Count(summary))
+		condensed.Raw = summary
+	}
+	return &condensed, nil
+}
+
+// summarizeChunk asks the LLM for a short summary of a chunk of page content.
+func (l *Client) summarizeChunk(chunk string) (string, error) {
+	resp, err := l.createChatCompletion(openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: llm.SummaryPrompt},
+			{Role: "user", Content: chunk},
+		},
+		MaxTokens: llm.SummaryTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	summary, err := llm.ChoiceContent(resp)
+	if errors.Is(err, llm.ErrTruncated) {
+		// a summary cut short is still a summary
+		return summary, nil
+	}
+	return summary, err
+}
+
+// suggestTags has the model call the llm.TagTool, so that its answer is structured and
+// limited to tagOptions. Once the model rejects tools, plain text answers are requested instead.
+func (l *Client) suggestTags(messages []openai.ChatCompletionMessage, tagOptions []string) (llm.TagSuggestion, error) {
+	req := openai.ChatCompletionRequest{Messages: messages}
+	if !l.toolsUnsupported.Load() {
+		req.Tools = []openai.Tool{llm.TagTool(tagOptions)}
+		req.ToolChoice = llm.TagToolChoice()
+	}
 
-	return llm.SplitResponse(response), nil
+	resp, err := l.createChatCompletion(req)
+	if req.Tools != nil && llm.IsToolsUnsupported(err) {
+		slog.Warn("Model doesn't support tool calling, falling back to plain text output", "model", l.Model)
+		l.toolsUnsupported.Store(true)
+		req.Tools, req.ToolChoice = nil, nil
+		resp, err = l.createChatCompletion(req)
+	}
+	if err != nil {
+		return llm.TagSuggestion{}, err
+	}
+	return llm.ParseTagSuggestion(resp)
 }
 
-// FetchPages returns a list of page details from the database.
-func (l *Client) FetchPages(databaseID string, untagged bool) ([]notionTypes.PageDetail, error) {
-	pages, err := l.ListPages(databaseID, untagged)
+// FetchPages returns a list of page details from the database matching query.
+func (l *Client) FetchPages(databaseID string, query notion.DatabaseQuery) ([]notionTypes.PageDetail, error) {
+	pages, err := l.IteratePages(databaseID, query).Collect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pages: %w", err)
 	}
 
 	var pageDetails []notionTypes.PageDetail
 	for _, page := range pages {
-		pageProps, ok := page.Properties.(notion.DatabasePageProperties)
-		if !ok {
+		if _, ok := page.Properties.(notion.DatabasePageProperties); !ok {
 			return nil, fmt.Errorf("failed to convert page properties to notion.DatabasePageProperties")
 		}
-		name := pageProps["Name"].Title[0].PlainText
 		pageDetails = append(pageDetails, notionTypes.PageDetail{
 			ID:   page.ID,
-			Name: name,
+			Name: notionTypes.PageTitle(&page),
 		})
 	}
 
@@ -120,20 +329,51 @@ func (l *Client) FetchPages(databaseID string, untagged bool) ([]notionTypes.Pag
 }
 
 func (l *Client) TagPage(id string, availableTags []string) error {
+	_, err := l.tagPage(id, availableTags)
+	return err
+}
+
+// tagPage tags a page and returns the tags written to it, along with the rejected suggestions
+// and how they differ from the current tags. With DryRun, the tags are only suggested.
+func (l *Client) tagPage(id string, availableTags []string) (TagResult, error) {
+	result := TagResult{PageID: id}
 	p, err := l.GetPage(id)
 	if err != nil {
-		return fmt.Errorf("failed to retrive Notion Page: %w", err)
+		return result, fmt.Errorf("failed to retrive Notion Page: %w", err)
 	}
+	result.Name = notionTypes.PageTitle(p.Page)
+	result.Current = notionTypes.PageMultiSelect(p.Page, TagColumn)
 
-	tagList, err := l.IdentifyTags(notionTypes.NewTagInput(p), availableTags)
+	input := notionTypes.NewTagInput(p, TagColumn)
+	identify := l.identifyTags
+	if l.Tagger != nil {
+		identify = func(input *llm.TagInput, tagOptions []string) (llm.TagValidation, error) {
+			tags, err := l.Tagger.IdentifyTags(input, tagOptions)
+			return llm.TagValidation{Tags: tags}, err
+		}
+	}
+	validation, err := identify(input, availableTags)
+	if errors.Is(err, llm.ErrTruncated) && len(input.Raw) > 0 {
+		slog.Warn("Response truncated, retrying with shorter content", "page", id)
+		input.Raw = strings.ToValidUTF8(input.Raw[:len(input.Raw)/2], "")
+		validation, err = identify(input, availableTags)
+	}
+	result.Rejected = validation.Rejected
 	if err != nil {
-		return fmt.Errorf("failed to identify tags for page %s: %w", id, err)
+		return result, fmt.Errorf("failed to identify tags for page %s: %w", id, err)
+	}
+	result.Confidence, result.Rationale = validation.Confidence, validation.Rationale
+	result.Tags = validation.Tags
+	result.Added, result.Removed = DiffTags(result.Current, result.Tags)
+	if l.DryRun {
+		return result, nil
 	}
 
-	slog.Info("Tagging page", "page", id, "tags", strings.Join(tagList, ", "))
-	if err := l.TagDatabasePage(id, tagList); err != nil {
+	slog.Info("Tagging page", "page", id, "tags", strings.Join(validation.Tags, ", "))
+	if err := l.TagDatabasePage(id, validation.Tags); err != nil {
 		slog.Error("Failed to tag page", "page", id, "err", err)
-		return fmt.Errorf("failed to tag page %s: %w", id, err)
+		result.Tags, result.Added, result.Removed = nil, nil, nil
+		return result, fmt.Errorf("failed to tag page %s: %w", id, err)
 	}
-	return nil
+	return result, nil
 }
@@ -3,7 +3,9 @@ package pkg
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/klauern/notion-table-reader/pkg/llm"
@@ -61,8 +63,8 @@ func TestRequestChatCompletion_Failure(t *testing.T) {
 	// Test failed request
 	messages := []openai.ChatCompletionMessage{{Content: "Test message"}}
 	_, err := client.RequestChatCompletion(messages)
-	if err == nil || err.Error() != "error creating chat completion request after 3 attempts: error" {
-		t.Errorf("Expected error 'error creating chat completion request after 3 attempts: error', but got: %v", err)
+	if err == nil || err.Error() != "chat completion request failed: error" {
+		t.Errorf("Expected error 'chat completion request failed: error', but got: %v", err)
 	}
 }
 
@@ -152,7 +154,190 @@ func TestIdentifyTags(t *testing.T) {
 	}
 
 	_, err = client.IdentifyTags(tagInput, []string{"tag1", "tag2", "tag3"})
-	if err == nil || err.Error() != "error creating chat completion request after 3 attempts: error" {
-		t.Errorf("Expected error 'error creating chat completion request after 3 attempts: error', but got: %v", err)
+	if err == nil || err.Error() != "chat completion request failed: error" {
+		t.Errorf("Expected error 'chat completion request failed: error', but got: %v", err)
+	}
+}
+
+func TestRequestChatCompletion_NoChoices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mocks.NewMockOpenAIClient(ctrl)
+	mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, nil)
+
+	client := Client{
+		LLMClient: mockClient,
+		context:   context.Background(),
+		Model:     "test-model",
+		MaxTokens: 100,
+	}
+
+	_, err := client.RequestChatCompletion([]openai.ChatCompletionMessage{{Content: "Test message"}})
+	if !errors.Is(err, llm.ErrNoChoices) {
+		t.Errorf("Expected ErrNoChoices, but got: %v", err)
+	}
+}
+
+func TestIdentifyTags_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mocks.NewMockOpenAIClient(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "- Tag1\n- invented\n"}}},
+		}, nil),
+		mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "invented"}}},
+		}, nil),
+	)
+
+	client := Client{
+		LLMClient: mockClient,
+		context:   context.Background(),
+		Model:     "test-model",
+		MaxTokens: 100,
+	}
+	tagInput := &llm.TagInput{Title: "Test Title"}
+
+	tags, err := client.IdentifyTags(tagInput, []string{"tag1", "tag2"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"tag1"}) {
+		t.Errorf("Expected tags [tag1], but got %v", tags)
+	}
+
+	_, err = client.IdentifyTags(tagInput, []string{"tag1", "tag2"})
+	if !errors.Is(err, llm.ErrNoValidTags) {
+		t.Errorf("Expected ErrNoValidTags, but got: %v", err)
+	}
+}
+
+func TestIdentifyTags_TagMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mocks.NewMockOpenAIClient(ctrl)
+	var system string
+	mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		system = req.Messages[0].Content
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "k8s, go"}}},
+		}, nil
+	})
+
+	client := Client{
+		LLMClient:   mockClient,
+		context:     context.Background(),
+		Model:       "test-model",
+		MaxTokens:   100,
+		TagMetadata: llm.TagMetadata{"kubernetes": {Description: "Container orchestration", Synonyms: []string{"k8s"}}},
+	}
+
+	tags, err := client.IdentifyTags(&llm.TagInput{Title: "Test Title"}, []string{"kubernetes", "go"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"kubernetes", "go"}) {
+		t.Errorf("Expected tags [kubernetes go], but got %v", tags)
+	}
+	if !strings.Contains(system, "- kubernetes: Container orchestration. Also called: k8s.") {
+		t.Errorf("Expected the system prompt to describe kubernetes, but got: %s", system)
+	}
+}
+
+func TestIdentifyTags_ToolsUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mocks.NewMockOpenAIClient(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			if len(req.Tools) != 1 {
+				t.Errorf("Expected the tag tool, but got: %v", req.Tools)
+			}
+			return openai.ChatCompletionResponse{}, &openai.APIError{HTTPStatusCode: 400, Message: "model does not support tools"}
+		}),
+		mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			if len(req.Tools) != 0 {
+				t.Errorf("Expected no tools, but got: %v", req.Tools)
+			}
+			return openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "tag1"}}},
+			}, nil
+		}).Times(2),
+	)
+
+	client := Client{
+		LLMClient: mockClient,
+		context:   context.Background(),
+		Model:     "test-model",
+		MaxTokens: 100,
+	}
+
+	for i := 0; i < 2; i++ {
+		tags, err := client.IdentifyTags(&llm.TagInput{Title: "Test Title"}, []string{"tag1", "tag2"})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(tags, []string{"tag1"}) {
+			t.Errorf("Expected tags [tag1], but got %v", tags)
+		}
+	}
+}
+
+func TestNewClient_ModelLimits(t *testing.T) {
+	client := NewClient(context.Background(), "openai_key", "notion_api_key")
+	if client.MaxTokens == 0 || client.ContextWindow == 0 {
+		t.Errorf("Expected token limits for %s, but got %d and %d", client.Model, client.MaxTokens, client.ContextWindow)
+	}
+
+	if err := client.UseModel("unknown-model", nil); err == nil {
+		t.Error("Expected an error for an unknown model")
+	}
+	if err := client.UseModel("llama3.1", map[string]llm.ModelInfo{"llama3.1": {ContextWindow: 8192, MaxOutputTokens: 1024}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if client.Model != "llama3.1" || client.MaxTokens != 1024 || client.ContextWindow != 8192 {
+		t.Errorf("Expected llama3.1 with its limits, but got %s, %d and %d", client.Model, client.MaxTokens, client.ContextWindow)
+	}
+}
+
+func TestIdentifyTags_Summarize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mocks.NewMockOpenAIClient(ctrl)
+	var summaries int
+	var tagPrompt string
+	mockClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		if len(req.Tools) == 0 {
+			summaries++
+			if req.MaxTokens != llm.SummaryTokens {
+				t.Errorf("Expected summaries of %d tokens, but got %d", llm.SummaryTokens, req.MaxTokens)
+			}
+			return openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: fmt.Sprintf("Summary %d.", summaries)}}},
+			}, nil
+		}
+		tagPrompt = req.Messages[1].Content
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "tag1"}}},
+		}, nil
+	}).AnyTimes()
+
+	client := Client{
+		LLMClient:       mockClient,
+		context:         context.Background(),
+		Model:           "test-model",
+		MaxTokens:       100,
+		ContextWindow:   2000,
+		ContentStrategy: llm.StrategySummarize,
+	}
+
+	tags, err := client.IdentifyTags(&llm.TagInput{Title: "Long read", Raw: strings.Repeat("A sentence about Go. ", 1000)}, []string{"tag1", "tag2"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"tag1"}) {
+		t.Errorf("Expected tags [tag1], but got %v", tags)
+	}
+	if summaries < 2 {
+		t.Errorf("Expected the content to be summarized in chunks, but got %d summaries", summaries)
+	}
+	if !strings.Contains(tagPrompt, "Summary 1.\n\nSummary 2.") {
+		t.Errorf("Expected the summaries in the prompt, but got: %s", tagPrompt)
 	}
 }
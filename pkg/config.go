@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the config file looked up in the user config directory.
+const ConfigFileName = "notion-tagger.yaml"
+
+// Config holds the settings that can be provided through a config file.
+type Config struct {
+	// Database is a database ID, URL or title.
+	Database string `yaml:"database"`
+	// Pagination controls the page size and cap of Notion listings.
+	Pagination Pagination `yaml:"pagination"`
+	// Blocks controls how deep and how concurrently page content is fetched.
+	Blocks BlockTreeOptions `yaml:"blocks"`
+	// RateLimit controls the throttling and retries of Notion API requests.
+	RateLimit notionTypes.RetryOptions `yaml:"rate_limit"`
+	// LLM selects the provider serving chat completions.
+	LLM llm.ProviderConfig `yaml:"llm"`
+	// Model is the model tagging pages, the provider's default when unset.
+	Model string `yaml:"model"`
+	// Models describes models missing from the built-in registry, such as local models.
+	Models map[string]llm.ModelInfo `yaml:"models"`
+	// LLMRetry controls how failed LLM requests are retried.
+	LLMRetry llm.RetryPolicy `yaml:"llm_retry"`
+	// Prompts overrides the built-in prompt templates.
+	Prompts llm.PromptConfig `yaml:"prompts"`
+	// Examples controls the few-shot examples drawn from pages tagged by hand.
+	Examples ExampleOptions `yaml:"examples"`
+	// Embeddings controls tagging with embeddings instead of chat completions.
+	Embeddings EmbeddingOptions `yaml:"embeddings"`
+	// Search controls the local search index.
+	Search SearchOptions `yaml:"search"`
+	// TagsFile is the tag metadata file, TagsFileName next to the config file when unset.
+	TagsFile string `yaml:"tags_file"`
+}
+
+// DefaultConfigPath returns the path of the config file in the user config directory.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ConfigFileName
+	}
+	return filepath.Join(dir, ConfigFileName)
+}
+
+// configRelative resolves path against the directory of the config file at configPath,
+// using fallback when path is empty.
+func configRelative(configPath, path, fallback string) string {
+	if path == "" {
+		path = fallback
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
+
+// LoadConfig reads the config file at path. A missing file yields an empty Config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := cfg.Pagination.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pagination in config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
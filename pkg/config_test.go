@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte("database: Reading Inbox\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Database != "Reading Inbox" {
+		t.Errorf("Expected database 'Reading Inbox', but got: %v", cfg.Database)
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), ConfigFileName))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Database != "" {
+		t.Errorf("Expected empty database, but got: %v", cfg.Database)
+	}
+}
+
+func TestLoadConfig_InvalidPageSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte("pagination:\n  page_size: 500\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "page_size must be between 1 and 100, got 500") {
+		t.Errorf("Expected a page_size error, but got: %v", err)
+	}
+}
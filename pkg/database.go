@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	"github.com/dstotijn/go-notion"
 	readNotion "github.com/klauern/notion-table-reader/pkg/notion"
@@ -44,30 +46,111 @@ func (l *Client) ListMultiSelectProps(databaseId, columnName string) ([]string,
 	return nil, fmt.Errorf("Unable to find column %s", columnName)
 }
 
+// IterateSearch returns an iterator over all pages and databases matching opts.
+func (l *Client) IterateSearch(opts notion.SearchOpts) *Iterator[interface{}] {
+	return NewIterator(l.Pagination, func(cursor string, pageSize int) ([]interface{}, *string, bool, error) {
+		opts.StartCursor = cursor
+		opts.PageSize = pageSize
+		resp, err := l.NotionClient.Search(l.context, &opts)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return resp.Results, resp.NextCursor, resp.HasMore, nil
+	})
+}
+
 func (l *Client) ListDatabases(query string) ([]notion.Database, error) {
-	resp, err := l.NotionClient.Search(l.context, &notion.SearchOpts{
+	it := l.IterateSearch(notion.SearchOpts{
 		Query: query,
 		Filter: &notion.SearchFilter{
 			Value:    "database",
 			Property: "object",
 		},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("Error querying for databases: %w", err)
-	}
 
 	var databases []notion.Database
-	for _, result := range resp.Results {
-		if database, ok := result.(notion.Database); ok {
+	for it.Next() {
+		if database, ok := it.Value().(notion.Database); ok {
 			if database.Title != nil && len(database.Title) > 0 && database.Title[0].PlainText != "" {
 				databases = append(databases, database)
 			}
 		}
 	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("Error querying for databases: %w", err)
+	}
 
 	return databases, nil
 }
 
+// DatabaseSchema returns the properties of a database, keyed by name.
+func (l *Client) DatabaseSchema(databaseId string) (notion.DatabaseProperties, error) {
+	database, err := l.NotionClient.FindDatabaseByID(l.context, databaseId)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding database: %w", err)
+	}
+	return database.Properties, nil
+}
+
+var databaseIDPattern = regexp.MustCompile(`(?i)[0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12}`)
+
+// ParseDatabaseID extracts a database ID from a raw ID or a Notion URL.
+// It returns false if ref contains no ID.
+func ParseDatabaseID(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, "/") {
+		// the database ID is the last one in the path, ahead of any ?v= view ID
+		ref, _, _ = strings.Cut(ref, "?")
+		ids := databaseIDPattern.FindAllString(ref, -1)
+		if len(ids) == 0 {
+			return "", false
+		}
+		return strings.ReplaceAll(ids[len(ids)-1], "-", ""), true
+	}
+	if id := databaseIDPattern.FindString(ref); id == ref {
+		return strings.ReplaceAll(id, "-", ""), true
+	}
+	return "", false
+}
+
+// ResolveDatabaseID resolves a database ID, URL or title to a database ID.
+// Titles are looked up with ListDatabases and must match exactly one database.
+func (l *Client) ResolveDatabaseID(ref string) (string, error) {
+	if strings.TrimSpace(ref) == "" {
+		return "", errors.New("no database given")
+	}
+	if id, ok := ParseDatabaseID(ref); ok {
+		return id, nil
+	}
+
+	dbs, err := l.ListDatabases(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up database %q: %w", ref, err)
+	}
+	var matches []notion.Database
+	for _, db := range dbs {
+		if strings.EqualFold(databaseTitle(db), ref) {
+			matches = append(matches, db)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no database titled %q", ref)
+	case 1:
+		return strings.ReplaceAll(matches[0].ID, "-", ""), nil
+	default:
+		return "", fmt.Errorf("%d databases titled %q, use the database ID or URL instead", len(matches), ref)
+	}
+}
+
+func databaseTitle(db notion.Database) string {
+	var title strings.Builder
+	for _, t := range db.Title {
+		title.WriteString(t.PlainText)
+	}
+	return title.String()
+}
+
 func (l *Client) ListTagsForDatabaseColumn(databaseId, columnName string) ([]string, error) {
 	database, err := l.NotionClient.FindDatabaseByID(l.context, databaseId)
 	if err != nil {
@@ -87,21 +170,43 @@ func (l *Client) ListTagsForDatabaseColumn(databaseId, columnName string) ([]str
 	return nil, errors.New("No columns found")
 }
 
-func (l *Client) ListPages(databaseId string, notTagged bool) ([]notion.Page, error) {
-	results, err := l.NotionClient.QueryDatabase(l.context, databaseId, &notion.DatabaseQuery{
-		Filter: &notion.DatabaseQueryFilter{
-			Property: "Tags",
-			DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
-				MultiSelect: &notion.MultiSelectDatabaseQueryFilter{
-					IsEmpty: true,
-				},
-			},
-		},
+// IteratePages returns an iterator over all pages in a database matching query.
+func (l *Client) IteratePages(databaseId string, query notion.DatabaseQuery) *Iterator[notion.Page] {
+	return NewIterator(l.Pagination, func(cursor string, pageSize int) ([]notion.Page, *string, bool, error) {
+		query.StartCursor = cursor
+		query.PageSize = pageSize
+		resp, err := l.NotionClient.QueryDatabase(l.context, databaseId, &query)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return resp.Results, resp.NextCursor, resp.HasMore, nil
 	})
+}
+
+// ListPages lists the pages in a database matching filter, all pages when filter is nil.
+func (l *Client) ListPages(databaseId string, filter *notion.DatabaseQueryFilter) ([]notion.Page, error) {
+	results, err := l.IteratePages(databaseId, notion.DatabaseQuery{
+		Filter: filter,
+	}).Collect()
 	if err != nil {
 		return nil, fmt.Errorf("Error querying database: %w", err)
 	}
-	return results.Results, nil
+	return results, nil
+}
+
+// IterateBlockChildren returns an iterator over all the children of a block or page.
+// Pagination.Limit caps listings only, so page content is never cut short.
+func (l *Client) IterateBlockChildren(blockId string) *Iterator[notion.Block] {
+	return NewIterator(Pagination{PageSize: l.Pagination.PageSize}, func(cursor string, pageSize int) ([]notion.Block, *string, bool, error) {
+		resp, err := l.NotionClient.FindBlockChildrenByID(l.context, blockId, &notion.PaginationQuery{
+			StartCursor: cursor,
+			PageSize:    pageSize,
+		})
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return resp.Results, resp.NextCursor, resp.HasMore, nil
+	})
 }
 
 func (l *Client) GetPage(pageId string) (*readNotion.PageWithBlocks, error) {
@@ -110,14 +215,14 @@ func (l *Client) GetPage(pageId string) (*readNotion.PageWithBlocks, error) {
 		return nil, fmt.Errorf("Error finding page: %w", err)
 	}
 	slog.Debug("page", "id", page.ID, "parent_id", page.Parent.PageID)
-	blocks, err := l.NotionClient.FindBlockChildrenByID(l.context, page.ID, &notion.PaginationQuery{})
+	blocks, err := l.FetchBlockTree(page.ID)
 	if err != nil {
 		return nil, fmt.Errorf("Error finding blocks: %w", err)
 	}
 
 	pageWithBlocks := readNotion.PageWithBlocks{
 		Page:   &page,
-		Blocks: blocks.Results,
+		Blocks: blocks,
 	}
 
 	return &pageWithBlocks, nil
@@ -49,10 +49,10 @@ func TestExtractRichText(t *testing.T) {
 	}
 
 	pageWithBlocks := &myNotion.PageWithBlocks{
-		Blocks: blocks,
+		Blocks: myNotion.NewBlockNodes(blocks...),
 	}
 
-	expected := "HelloWorldHeading 1Heading 2Heading 3Item 1"
+	expected := "HelloWorld\n\n# Heading 1\n\n## Heading 2\n\n### Heading 3\n\n- Item 1"
 	result := pageWithBlocks.NormalizeBody()
 
 	Expect(result).To(Equal(expected))
@@ -153,7 +153,7 @@ func TestListPages(t *testing.T) {
 		Results: expectedPages,
 	}, nil)
 
-	pages, err := client.ListPages(databaseId, true)
+	pages, err := client.ListPages(databaseId, pkg.UntaggedFilter(pkg.TagColumn))
 	Expect(err).To(BeNil())
 	Expect(pages).To(Equal(expectedPages))
 }
@@ -169,9 +169,9 @@ func TestGetPage(t *testing.T) {
 	pageId := "test-page-id"
 	expectedPage := &myNotion.PageWithBlocks{
 		Page: &notion.Page{ID: pageId},
-		Blocks: []notion.Block{
+		Blocks: myNotion.NewBlockNodes(
 			&notion.ParagraphBlock{RichText: []notion.RichText{{PlainText: "Hello"}}},
-		},
+		),
 	}
 
 	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), pageId).Return(notion.Page{ID: pageId}, nil)
@@ -210,6 +210,7 @@ func TestTagDatabasePage(t *testing.T) {
 }
 
 func TestBlockToMarkdown(t *testing.T) {
+	RegisterTestingT(t)
 	paragraphBlock := &notion.ParagraphBlock{
 		RichText: []notion.RichText{
 			{PlainText: "Hello"},
@@ -227,7 +228,7 @@ func TestBlockToMarkdown(t *testing.T) {
 			{PlainText: "Heading 1"},
 		},
 	}
-	expected = "Heading 1"
+	expected = "# Heading 1"
 	result = myNotion.BlockToMarkdown(heading1Block)
 	Expect(result).To(Equal(expected))
 
@@ -236,7 +237,7 @@ func TestBlockToMarkdown(t *testing.T) {
 			{PlainText: "Heading 2"},
 		},
 	}
-	expected = "Heading 2"
+	expected = "## Heading 2"
 	result = myNotion.BlockToMarkdown(heading2Block)
 	Expect(result).To(Equal(expected))
 
@@ -245,7 +246,7 @@ func TestBlockToMarkdown(t *testing.T) {
 			{PlainText: "Heading 3"},
 		},
 	}
-	expected = "Heading 3"
+	expected = "### Heading 3"
 	result = myNotion.BlockToMarkdown(heading3Block)
 	Expect(result).To(Equal(expected))
 
@@ -254,7 +255,7 @@ func TestBlockToMarkdown(t *testing.T) {
 			{PlainText: "Item 1"},
 		},
 	}
-	expected = "Item 1"
+	expected = "- Item 1"
 	result = myNotion.BlockToMarkdown(bulletedListItemBlock)
 	Expect(result).To(Equal(expected))
 }
@@ -280,10 +281,10 @@ func TestNormalizeBody(t *testing.T) {
 	}
 
 	pageWithBlocks := &myNotion.PageWithBlocks{
-		Blocks: blocks,
+		Blocks: myNotion.NewBlockNodes(blocks...),
 	}
 
-	expected := "HelloWorldHeading 1Item 1"
+	expected := "HelloWorld\n\n# Heading 1\n\n- Item 1"
 	result := pageWithBlocks.NormalizeBody()
 
 	if result != expected {
@@ -301,3 +302,102 @@ func TestTagsToNotionProps(t *testing.T) {
 	result := pkg.TagsToNotionProps(tags)
 	Expect(result).To(Equal(expected))
 }
+
+func TestParseDatabaseID(t *testing.T) {
+	RegisterTestingT(t)
+	cases := map[string]string{
+		"2ce556682898478d8e9d175badac759e":     "2ce556682898478d8e9d175badac759e",
+		"2ce55668-2898-478d-8e9d-175badac759e": "2ce556682898478d8e9d175badac759e",
+		"https://www.notion.so/team/Inbox-2ce556682898478d8e9d175badac759e?v=0a1b2c3d4e5f60718293a4b5c6d7e8f9": "2ce556682898478d8e9d175badac759e",
+		"notion.so/2ce556682898478d8e9d175badac759e":                                                           "2ce556682898478d8e9d175badac759e",
+	}
+	for ref, expected := range cases {
+		id, ok := pkg.ParseDatabaseID(ref)
+		Expect(ok).To(BeTrue(), ref)
+		Expect(id).To(Equal(expected), ref)
+	}
+
+	_, ok := pkg.ParseDatabaseID("Reading Inbox")
+	Expect(ok).To(BeFalse())
+}
+
+func TestResolveDatabaseID(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	mockNotionClient.EXPECT().Search(gomock.Any(), gomock.Any()).Return(notion.SearchResponse{
+		Results: []interface{}{
+			notion.Database{ID: "2ce55668-2898-478d-8e9d-175badac759e", Title: []notion.RichText{{PlainText: "Inbox"}}},
+			notion.Database{ID: "11111111-2222-3333-4444-555555555555", Title: []notion.RichText{{PlainText: "Inbox Archive"}}},
+		},
+	}, nil).Times(2)
+
+	id, err := client.ResolveDatabaseID("inbox")
+	Expect(err).To(BeNil())
+	Expect(id).To(Equal("2ce556682898478d8e9d175badac759e"))
+
+	_, err = client.ResolveDatabaseID("Reading")
+	Expect(err).To(MatchError(`no database titled "Reading"`))
+
+	id, err = client.ResolveDatabaseID("2ce556682898478d8e9d175badac759e")
+	Expect(err).To(BeNil())
+	Expect(id).To(Equal("2ce556682898478d8e9d175badac759e"))
+}
+
+func TestListPages_Paginated(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	next := "cursor-2"
+	first := mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, query *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+			Expect(query.StartCursor).To(BeEmpty())
+			return notion.DatabaseQueryResponse{Results: []notion.Page{{ID: "page-1"}}, HasMore: true, NextCursor: &next}, nil
+		})
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, query *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+			Expect(query.StartCursor).To(Equal(next))
+			return notion.DatabaseQueryResponse{Results: []notion.Page{{ID: "page-2"}}}, nil
+		}).After(first)
+
+	pages, err := client.ListPages("db", nil)
+	Expect(err).To(BeNil())
+	Expect(pages).To(Equal([]notion.Page{{ID: "page-1"}, {ID: "page-2"}}))
+}
+
+func TestFetchPages_UntitledPage(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	untitled := notion.Page{ID: "page-2", Properties: notion.DatabasePageProperties{
+		"Name": notion.DatabasePageProperty{Type: notion.DBPropTypeTitle, Title: []notion.RichText{}},
+	}}
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).Return(notion.DatabaseQueryResponse{Results: []notion.Page{
+		{ID: "page-1", Properties: notion.DatabasePageProperties{
+			"Name": notion.DatabasePageProperty{Type: notion.DBPropTypeTitle, Title: []notion.RichText{{PlainText: "Titled"}}},
+		}},
+		untitled,
+	}}, nil)
+
+	pages, err := client.FetchPages("db", notion.DatabaseQuery{})
+	Expect(err).To(BeNil())
+	Expect(pages).To(Equal([]myNotion.PageDetail{{ID: "page-1", Name: "Titled"}, {ID: "page-2"}}))
+
+	input := myNotion.NewTagInput(&myNotion.PageWithBlocks{Page: &untitled}, pkg.TagColumn)
+	Expect(input.Title).To(BeEmpty())
+}
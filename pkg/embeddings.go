@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Taggers that can tag pages.
+const (
+	// TaggerLLM asks the LLM for tags with a chat completion.
+	TaggerLLM = "llm"
+	// TaggerEmbeddings compares the embedding of the page with the centroids of the tags.
+	TaggerEmbeddings = "embeddings"
+)
+
+// Taggers lists the accepted tagger names.
+var Taggers = []string{TaggerLLM, TaggerEmbeddings}
+
+// Tagger identifies the tags of a page among tagOptions.
+type Tagger interface {
+	IdentifyTags(messageContent *llm.TagInput, tagOptions []string) ([]string, error)
+}
+
+var (
+	_ Tagger = (*Client)(nil)
+	_ Tagger = (*EmbeddingTagger)(nil)
+)
+
+const (
+	// CentroidsFileName is the name of the centroids cache, looked up next to the config file.
+	CentroidsFileName = "notion-tagger-centroids.json"
+	// DefaultSimilarityThreshold is the lowest similarity of a page to a tag centroid for the tag to be suggested.
+	DefaultSimilarityThreshold = 0.3
+)
+
+// EmbeddingOptions controls tagging with embeddings.
+type EmbeddingOptions struct {
+	// Model is the embedding model, the provider's default when unset.
+	Model string `yaml:"model"`
+	// TopK is the most tags suggested for a page, llm.MaxTags when unset.
+	TopK int `yaml:"top_k"`
+	// Threshold is the lowest similarity of a suggested tag, DefaultSimilarityThreshold when unset.
+	Threshold *float64 `yaml:"threshold"`
+	// CentroidsFile caches the tag centroids, CentroidsFileName next to the config file when unset.
+	CentroidsFile string `yaml:"centroids_file"`
+}
+
+// WithDefaults fills in the unset options.
+func (o EmbeddingOptions) WithDefaults() EmbeddingOptions {
+	if o.TopK <= 0 {
+		o.TopK = llm.MaxTags
+	}
+	if o.Threshold == nil {
+		threshold := DefaultSimilarityThreshold
+		o.Threshold = &threshold
+	}
+	return o
+}
+
+// CentroidsPath returns the path of the centroids cache. A relative centroids_file is
+// resolved against the directory of the config file at configPath.
+func (c *Config) CentroidsPath(configPath string) string {
+	return configRelative(configPath, c.Embeddings.CentroidsFile, CentroidsFileName)
+}
+
+// LoadCentroids reads the centroids cache at path.
+func LoadCentroids(path string) (*llm.Centroids, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no tag centroids at %s: run tags train first", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag centroids %s: %w", path, err)
+	}
+	centroids := &llm.Centroids{}
+	if err := json.Unmarshal(data, centroids); err != nil {
+		return nil, fmt.Errorf("failed to parse tag centroids %s: %w", path, err)
+	}
+	return centroids, nil
+}
+
+// SaveCentroids writes the centroids cache at path.
+func SaveCentroids(path string, centroids *llm.Centroids) error {
+	data, err := json.Marshal(centroids)
+	if err != nil {
+		return fmt.Errorf("failed to encode tag centroids: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write tag centroids %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write tag centroids %s: %w", path, err)
+	}
+	return nil
+}
+
+// Embed returns the embeddings of texts with the embedding model, in the order of texts.
+func (l *Client) Embed(texts []string) ([][]float32, error) {
+	if l.Embedder == nil || l.EmbeddingModel == "" {
+		return nil, fmt.Errorf("no embedding model configured: set model under embeddings in the config file")
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += llm.EmbeddingBatchSize {
+		batch := texts[start:min(start+llm.EmbeddingBatchSize, len(texts))]
+		resp, err := llm.Retry(l.context, l.Retry, l.rateLimits, "embedding request", func(ctx context.Context) (openai.EmbeddingResponse, error) {
+			return l.Embedder.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+				Input: batch,
+				Model: openai.EmbeddingModel(l.EmbeddingModel),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("embedding request returned %d embeddings for %d texts", len(resp.Data), len(batch))
+		}
+		sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+		for _, embedding := range resp.Data {
+			vectors = append(vectors, embedding.Embedding)
+		}
+	}
+	return vectors, nil
+}
+
+// TrainCentroids computes the centroids of the tags from the embeddings of the tagged
+// pages of the database. progress, if set, is called with the number of pages embedded so far.
+func (l *Client) TrainCentroids(databaseId string, progress func(done int)) (*llm.Centroids, error) {
+	tok := llm.TokenizerForModel(l.EmbeddingModel)
+	trainer := llm.NewCentroidTrainer(l.EmbeddingModel)
+	var texts []string
+	var tags [][]string
+	done := 0
+	flush := func() error {
+		vectors, err := l.Embed(texts)
+		if err != nil {
+			return err
+		}
+		for i, vector := range vectors {
+			trainer.Add(tags[i], vector)
+		}
+		done += len(texts)
+		if progress != nil {
+			progress(done)
+		}
+		texts, tags = texts[:0], tags[:0]
+		return nil
+	}
+
+	it := l.IteratePages(databaseId, notion.DatabaseQuery{Filter: TaggedFilter(TagColumn)})
+	for it.Next() {
+		page := it.Value()
+		p, err := l.GetPage(page.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrive Notion Page: %w", err)
+		}
+		texts = append(texts, llm.EmbeddingText(notionTypes.NewTagInput(p, TagColumn), tok))
+		tags = append(tags, notionTypes.PageMultiSelect(&page, TagColumn))
+		if len(texts) == llm.EmbeddingBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tagged pages: %w", err)
+	}
+	if len(texts) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	if done == 0 {
+		return nil, fmt.Errorf("no tagged pages to train on")
+	}
+	centroids := trainer.Centroids()
+	centroids.DatabaseID = databaseId
+	return centroids, nil
+}
+
+// EmbeddingTagger identifies tags by comparing the embedding of a page with the centroids
+// of the tags, computed from the pages already tagged. It needs no chat completion, and
+// tags a page the same way every time.
+type EmbeddingTagger struct {
+	Client    *Client
+	Centroids *llm.Centroids
+	Options   EmbeddingOptions
+}
+
+// NewEmbeddingTagger returns a tagger for the pages of the database databaseId, embedding
+// pages with client. The centroids must have been trained on that database, with the
+// embedding model of client.
+func NewEmbeddingTagger(client *Client, centroids *llm.Centroids, databaseId string, opts EmbeddingOptions) (*EmbeddingTagger, error) {
+	if centroids.Model != client.EmbeddingModel {
+		return nil, fmt.Errorf("tag centroids were trained with %s, not %s: run tags train again", centroids.Model, client.EmbeddingModel)
+	}
+	if centroids.DatabaseID != databaseId {
+		return nil, fmt.Errorf("tag centroids were trained on database %s, not %s: run tags train again", centroids.DatabaseID, databaseId)
+	}
+	return &EmbeddingTagger{Client: client, Centroids: centroids, Options: opts.WithDefaults()}, nil
+}
+
+// IdentifyTags returns the tags of tagOptions most similar to the page. It fails with
+// llm.ErrNoValidTags when no tag is similar enough.
+func (t *EmbeddingTagger) IdentifyTags(messageContent *llm.TagInput, tagOptions []string) ([]string, error) {
+	text := llm.EmbeddingText(messageContent, llm.TokenizerForModel(t.Client.EmbeddingModel))
+	vectors, err := t.Client.Embed([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	opts := t.Options.WithDefaults()
+	threshold := *opts.Threshold
+	scores := t.Centroids.Suggest(vectors[0], tagOptions, opts.TopK, threshold)
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("%w: no tag has a similarity of %g or more", llm.ErrNoValidTags, threshold)
+	}
+	tags := make([]string, len(scores))
+	similarities := make([]string, len(scores))
+	for i, score := range scores {
+		tags[i] = score.Tag
+		similarities[i] = fmt.Sprintf("%s=%.2f", score.Tag, score.Score)
+	}
+	slog.Info("Matched tag centroids", "title", messageContent.Title, "similarities", strings.Join(similarities, ", "))
+	return tags, nil
+}
@@ -0,0 +1,102 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeEmbeddings embeds texts mentioning Go along the first axis and others along the second.
+func fakeEmbeddings(_ context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	var resp openai.EmbeddingResponse
+	for i, text := range conv.Convert().Input.([]string) {
+		vector := []float32{0, 1}
+		if len(text) >= 2 && text[:2] == "Go" {
+			vector = []float32{1, 0.1}
+		}
+		resp.Data = append(resp.Data, openai.Embedding{Embedding: vector, Index: i})
+	}
+	return resp, nil
+}
+
+func TestTrainCentroids_EmbeddingTagger(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockEmbedder := mocks.NewMockEmbeddingClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.Embedder = mockEmbedder
+	client.EmbeddingModel = "test-embedding"
+
+	pages := map[string]notion.Page{
+		"generics": taggedPage("generics", "Go generics", "go"),
+		"modules":  taggedPage("modules", "Go modules", "go"),
+		"bread":    taggedPage("bread", "Sourdough bread", "cooking"),
+	}
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).Return(notion.DatabaseQueryResponse{
+		Results: []notion.Page{pages["generics"], pages["modules"], pages["bread"]},
+	}, nil)
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		return pages[id], nil
+	}).AnyTimes()
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(notion.BlockChildrenResponse{}, nil).AnyTimes()
+	mockEmbedder.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(fakeEmbeddings).AnyTimes()
+
+	var progress []int
+	centroids, err := client.TrainCentroids("db", func(done int) { progress = append(progress, done) })
+	Expect(err).To(BeNil())
+	Expect(progress).To(Equal([]int{3}))
+	Expect(centroids.Model).To(Equal("test-embedding"))
+	Expect(centroids.DatabaseID).To(Equal("db"))
+	Expect(centroids.Tags["go"].Pages).To(Equal(2))
+	Expect(centroids.Tags["cooking"].Pages).To(Equal(1))
+
+	path := filepath.Join(t.TempDir(), pkg.CentroidsFileName)
+	Expect(pkg.SaveCentroids(path, centroids)).To(Succeed())
+	loaded, err := pkg.LoadCentroids(path)
+	Expect(err).To(BeNil())
+	Expect(loaded.Tags).To(Equal(centroids.Tags))
+
+	threshold := 0.5
+	tagger, err := pkg.NewEmbeddingTagger(client, loaded, "db", pkg.EmbeddingOptions{Threshold: &threshold})
+	Expect(err).To(BeNil())
+	tags, err := tagger.IdentifyTags(&llm.TagInput{Title: "Go iterators"}, []string{"go", "cooking"})
+	Expect(err).To(BeNil())
+	Expect(tags).To(Equal([]string{"go"}))
+
+	_, err = tagger.IdentifyTags(&llm.TagInput{Title: "Go iterators"}, []string{"cooking"})
+	Expect(errors.Is(err, llm.ErrNoValidTags)).To(BeTrue())
+
+	threshold = 0
+	tagger, err = pkg.NewEmbeddingTagger(client, loaded, "db", pkg.EmbeddingOptions{Threshold: &threshold})
+	Expect(err).To(BeNil())
+	tags, err = tagger.IdentifyTags(&llm.TagInput{Title: "Go iterators"}, []string{"cooking"})
+	Expect(err).To(BeNil())
+	Expect(tags).To(Equal([]string{"cooking"}))
+
+	_, err = pkg.NewEmbeddingTagger(client, loaded, "other-db", pkg.EmbeddingOptions{})
+	Expect(err).To(MatchError("tag centroids were trained on database db, not other-db: run tags train again"))
+
+	client.EmbeddingModel = "other-embedding"
+	_, err = pkg.NewEmbeddingTagger(client, loaded, "db", pkg.EmbeddingOptions{})
+	Expect(err).To(MatchError(ContainSubstring("run tags train again")))
+}
+
+func TestLoadCentroids_Missing(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := pkg.LoadCentroids(filepath.Join(t.TempDir(), pkg.CentroidsFileName))
+	Expect(err).To(MatchError(ContainSubstring("run tags train first")))
+}
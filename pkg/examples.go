@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+)
+
+// Defaults of ExampleOptions.
+const (
+	DefaultExamplePool          = 200
+	DefaultExampleTokens        = 2000
+	DefaultExampleContentTokens = 200
+)
+
+// ExampleOptions controls the few-shot examples drawn from pages tagged by hand.
+// The zero value disables examples.
+type ExampleOptions struct {
+	// Count is the number of examples shown for each page, 0 disables them.
+	Count int `yaml:"count"`
+	// Pool is how many of the last edited tagged pages examples are chosen from.
+	Pool int `yaml:"pool"`
+	// Tokens is the token budget of all the examples of a page.
+	Tokens int `yaml:"tokens"`
+	// ContentTokens caps the content shown for each example.
+	ContentTokens int `yaml:"content_tokens"`
+}
+
+// WithDefaults fills in the unset options.
+func (o ExampleOptions) WithDefaults() ExampleOptions {
+	if o.Pool <= 0 {
+		o.Pool = DefaultExamplePool
+	}
+	if o.Tokens <= 0 {
+		o.Tokens = DefaultExampleTokens
+	}
+	if o.ContentTokens <= 0 {
+		o.ContentTokens = DefaultExampleContentTokens
+	}
+	return o
+}
+
+// LoadExamples samples the pages of the database already tagged, to show the most
+// relevant of them to the model as examples. Their content is fetched when first shown.
+func (l *Client) LoadExamples(databaseId string) error {
+	opts := l.Examples.WithDefaults()
+	it := l.IteratePages(databaseId, notion.DatabaseQuery{
+		Filter: TaggedFilter(TagColumn),
+		Sorts:  []notion.DatabaseQuerySort{{Timestamp: notion.SortTimeStampLastEditedTime, Direction: notion.SortDirDesc}},
+	})
+	var examples []llm.Example
+	for len(examples) < opts.Pool && it.Next() {
+		page := it.Value()
+		examples = append(examples, llm.Example{
+			ID:    page.ID,
+			Title: notionTypes.PageTitle(&page),
+			URL:   page.URL,
+			Tags:  notionTypes.PageMultiSelect(&page, TagColumn),
+		})
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to query tagged pages: %w", err)
+	}
+	slog.Debug("Loaded example pages", "count", len(examples))
+	l.examplePool = examples
+	return nil
+}
+
+// examples returns the examples most relevant to the page, with their content.
+// Pages whose content can't be fetched are shown by title only.
+func (l *Client) examples(input *llm.TagInput) []llm.Example {
+	if l.Examples.Count <= 0 || len(l.examplePool) == 0 {
+		return nil
+	}
+	var examples []llm.Example
+	for _, example := range llm.RankExamples(input, l.examplePool) {
+		if len(examples) == l.Examples.Count {
+			break
+		}
+		if example.URL != "" && example.URL == input.URL {
+			continue // the page itself, when tagging it again
+		}
+		if content, ok := l.exampleContent.Load(example.ID); ok {
+			example.Content = content.(string)
+		} else if page, err := l.GetPage(example.ID); err != nil {
+			slog.Warn("Failed to fetch example page", "page", example.ID, "err", err)
+		} else {
+			example.Content = page.NormalizeBody()
+			l.exampleContent.Store(example.ID, example.Content)
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
@@ -0,0 +1,67 @@
+package pkg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/mock/gomock"
+)
+
+func taggedPage(id, title string, tags ...string) notion.Page {
+	return notion.Page{ID: id, URL: "https://notion.so/" + id, Properties: notion.DatabasePageProperties{
+		"Name": {Type: notion.DBPropTypeTitle, Title: []notion.RichText{{PlainText: title}}},
+		"Tags": {Type: notion.DBPropTypeMultiSelect, MultiSelect: pkg.TagsToNotionProps(tags)},
+	}}
+}
+
+func TestIdentifyTags_Examples(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockLLMClient := mocks.NewMockOpenAIClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.LLMClient = mockLLMClient
+	client.Examples = pkg.ExampleOptions{Count: 1}
+
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, query *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+		Expect(query.Filter).To(Equal(pkg.TaggedFilter(pkg.TagColumn)))
+		return notion.DatabaseQueryResponse{Results: []notion.Page{
+			taggedPage("groceries", "Weekly groceries", "home"),
+			taggedPage("cilium", "Kubernetes networking with Cilium", "kubernetes"),
+		}}, nil
+	})
+	Expect(client.LoadExamples("db")).To(Succeed())
+
+	// the content of the example is fetched once
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "cilium").Return(taggedPage("cilium", "Kubernetes networking with Cilium", "kubernetes"), nil)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "cilium", gomock.Any()).Return(notion.BlockChildrenResponse{
+		Results: []notion.Block{&notion.ParagraphBlock{RichText: []notion.RichText{{PlainText: "eBPF based pod networking."}}}},
+	}, nil)
+	mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		Expect(req.Messages).To(HaveLen(4))
+		Expect(req.Messages[1].Role).To(Equal(openai.ChatMessageRoleUser))
+		Expect(req.Messages[1].Content).To(ContainSubstring("Title: Kubernetes networking with Cilium"))
+		Expect(req.Messages[1].Content).To(ContainSubstring("eBPF based pod networking."))
+		Expect(req.Messages[2]).To(Equal(openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "kubernetes"}))
+		Expect(req.Messages[3].Content).To(ContainSubstring("Title: Debugging Cilium"))
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "kubernetes"}}},
+		}, nil
+	}).Times(2)
+
+	input := &llm.TagInput{Title: "Debugging Cilium", Raw: "Pod networking broke after the upgrade."}
+	for i := 0; i < 2; i++ {
+		tags, err := client.IdentifyTags(input, []string{"home", "kubernetes"})
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]string{"kubernetes"}))
+	}
+}
@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	readNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultExportFilename is the filename template used when ExportOptions.Filename is unset.
+	DefaultExportFilename = "{{.Slug}}-{{.ShortID}}.md"
+	// ExportStateFile keeps track of exported pages for incremental exports.
+	ExportStateFile = ".notion-export.json"
+)
+
+// ExportOptions controls how pages are written by ExportPages.
+type ExportOptions struct {
+	// Dir is the directory the Markdown files are written to.
+	Dir string
+	// Filename is a text/template rendered with an ExportPage to name each file.
+	Filename string
+	// Force exports every page, even if it wasn't edited since the last export.
+	Force bool
+}
+
+// ExportResult counts the pages handled by ExportPages.
+type ExportResult struct {
+	Written int
+	Skipped int
+}
+
+// ExportPage holds the page details available to filename templates and front matter.
+type ExportPage struct {
+	ID             string    `yaml:"id"`
+	ShortID        string    `yaml:"-"`
+	Name           string    `yaml:"name"`
+	Slug           string    `yaml:"-"`
+	Tags           []string  `yaml:"tags"`
+	URL            string    `yaml:"url,omitempty"`
+	NotionURL      string    `yaml:"notion_url"`
+	CreatedTime    time.Time `yaml:"created_time"`
+	LastEditedTime time.Time `yaml:"last_edited_time"`
+}
+
+type exportState map[string]exportedPage
+
+type exportedPage struct {
+	File           string    `json:"file"`
+	LastEditedTime time.Time `json:"last_edited_time"`
+}
+
+// NewExportPage collects the exported details of a database page.
+func NewExportPage(page *notion.Page) ExportPage {
+	id := strings.ReplaceAll(page.ID, "-", "")
+	p := ExportPage{
+		ID:             page.ID,
+		ShortID:        id[:min(8, len(id))],
+		Name:           readNotion.PageTitle(page),
+		Tags:           readNotion.PageMultiSelect(page, TagColumn),
+		NotionURL:      page.URL,
+		CreatedTime:    page.CreatedTime,
+		LastEditedTime: page.LastEditedTime,
+	}
+	p.Slug = Slugify(p.Name)
+	if props, ok := page.Properties.(notion.DatabasePageProperties); ok && props["URL"].URL != nil {
+		p.URL = *props["URL"].URL
+	}
+	return p
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a title into a lower-case, dash separated file name.
+func Slugify(title string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if slug == "" {
+		return "untitled"
+	}
+	return slug
+}
+
+// ExportPages writes every page of the database as a Markdown file with YAML front matter.
+// Pages that weren't edited since the previous export to the same directory are skipped.
+func (l *Client) ExportPages(databaseId string, opts ExportOptions) (ExportResult, error) {
+	var result ExportResult
+	if opts.Filename == "" {
+		opts.Filename = DefaultExportFilename
+	}
+	filename, err := template.New("filename").Parse(opts.Filename)
+	if err != nil {
+		return result, fmt.Errorf("invalid filename template: %w", err)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return result, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	state, err := loadExportState(opts.Dir)
+	if err != nil {
+		return result, err
+	}
+
+	// state is saved even when a page fails, so finished pages are skipped next time
+	err = l.exportAll(databaseId, filename, opts, state, &result)
+	if saveErr := saveExportState(opts.Dir, state); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return result, err
+}
+
+func (l *Client) exportAll(databaseId string, filename *template.Template, opts ExportOptions, state exportState, result *ExportResult) error {
+	owners := map[string]string{} // files written or kept by this export, to the page owning them
+	it := l.IteratePages(databaseId, notion.DatabaseQuery{})
+	for it.Next() {
+		page := it.Value()
+		details := NewExportPage(&page)
+
+		var name bytes.Buffer
+		if err := filename.Execute(&name, details); err != nil {
+			return fmt.Errorf("failed to render filename for page %s: %w", page.ID, err)
+		}
+		file := filepath.Clean(name.String())
+		if !filepath.IsLocal(file) {
+			return fmt.Errorf("filename %q for page %s is outside of the export directory", file, page.ID)
+		}
+		if owner, ok := owners[file]; ok {
+			return fmt.Errorf("pages %s and %s both export to %s: add .ShortID to the filename template", owner, page.ID, file)
+		}
+		owners[file] = page.ID
+
+		previous, exported := state[page.ID]
+		if !opts.Force && exported && previous.File == file && previous.LastEditedTime.Equal(page.LastEditedTime) {
+			if _, err := os.Stat(filepath.Join(opts.Dir, file)); err == nil {
+				result.Skipped++
+				continue
+			}
+		}
+
+		if err := l.exportPage(page.ID, details, filepath.Join(opts.Dir, file)); err != nil {
+			return err
+		}
+		if _, owned := owners[previous.File]; exported && previous.File != file && !owned {
+			// the title changed, so don't leave the old file behind, unless another page now writes it
+			if err := os.Remove(filepath.Join(opts.Dir, previous.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Failed to remove previous export", "page", page.ID, "file", previous.File, "err", err)
+			}
+		}
+		state[page.ID] = exportedPage{File: file, LastEditedTime: page.LastEditedTime}
+		result.Written++
+		slog.Debug("Exported page", "page", page.ID, "file", file)
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to query pages: %w", err)
+	}
+	return nil
+}
+
+func (l *Client) exportPage(pageId string, details ExportPage, path string) error {
+	page, err := l.GetPage(pageId)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve page %s: %w", pageId, err)
+	}
+	frontMatter, err := yaml.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to render front matter for page %s: %w", pageId, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(frontMatter)
+	buf.WriteString("---\n\n")
+	if body := page.NormalizeBody(); body != "" {
+		buf.WriteString(body)
+		buf.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for page %s: %w", pageId, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write page %s: %w", pageId, err)
+	}
+	return nil
+}
+
+func loadExportState(dir string) (exportState, error) {
+	state := exportState{}
+	data, err := os.ReadFile(filepath.Join(dir, ExportStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse export state: %w", err)
+	}
+	return state, nil
+}
+
+func saveExportState(dir string, state exportState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ExportStateFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write export state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package pkg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportPages(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	edited := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	page := notion.Page{
+		ID:             "2ce55668-2898-478d-8e9d-175badac759e",
+		URL:            "https://www.notion.so/Hello-2ce556682898478d8e9d175badac759e",
+		CreatedTime:    edited.Add(-time.Hour),
+		LastEditedTime: edited,
+		Properties: notion.DatabasePageProperties{
+			"Name": notion.DatabasePageProperty{Type: notion.DBPropTypeTitle, Title: []notion.RichText{{PlainText: "Hello, World!"}}},
+			"Tags": notion.DatabasePageProperty{MultiSelect: []notion.SelectOptions{{Name: "go"}}},
+		},
+	}
+
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", &notion.DatabaseQuery{}).Return(notion.DatabaseQueryResponse{
+		Results: []notion.Page{page},
+	}, nil).Times(2)
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), page.ID).Return(page, nil).Times(1)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), page.ID, gomock.Any()).Return(notion.BlockChildrenResponse{
+		Results: []notion.Block{
+			&notion.ParagraphBlock{RichText: []notion.RichText{{PlainText: "Body"}}},
+		},
+	}, nil).Times(1)
+
+	dir := t.TempDir()
+	result, err := client.ExportPages("db", pkg.ExportOptions{Dir: dir})
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(pkg.ExportResult{Written: 1}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "hello-world-2ce55668.md"))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal(`---
+id: 2ce55668-2898-478d-8e9d-175badac759e
+name: Hello, World!
+tags:
+    - go
+notion_url: https://www.notion.so/Hello-2ce556682898478d8e9d175badac759e
+created_time: 2026-01-02T02:04:05Z
+last_edited_time: 2026-01-02T03:04:05Z
+---
+
+Body
+`))
+
+	// the page wasn't edited since, so it isn't fetched again
+	result, err = client.ExportPages("db", pkg.ExportOptions{Dir: dir})
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(pkg.ExportResult{Skipped: 1}))
+}
+
+func TestExportPages_Filenames(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	edited := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	page := func(id, title string) notion.Page {
+		p := taggedPage(id, title)
+		p.LastEditedTime = edited
+		return p
+	}
+	var listed []notion.Page
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).DoAndReturn(func(context.Context, string, *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+		return notion.DatabaseQueryResponse{Results: listed}, nil
+	}).AnyTimes()
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		for _, p := range listed {
+			if p.ID == id {
+				return p, nil
+			}
+		}
+		return notion.Page{}, nil
+	}).AnyTimes()
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string, _ *notion.PaginationQuery) (notion.BlockChildrenResponse, error) {
+		return notion.BlockChildrenResponse{Results: []notion.Block{
+			&notion.ParagraphBlock{RichText: []notion.RichText{{PlainText: "Body of " + id}}},
+		}}, nil
+	}).AnyTimes()
+
+	dir := t.TempDir()
+	opts := pkg.ExportOptions{Dir: dir, Filename: "{{.Slug}}.md"}
+	listed = []notion.Page{page("a", "Alpha"), page("b", "Beta")}
+	_, err := client.ExportPages("db", opts)
+	Expect(err).To(BeNil())
+
+	// a takes the name b had: b's rename must not delete a's new file
+	edited = edited.Add(time.Hour)
+	listed = []notion.Page{page("a", "Beta"), page("b", "Gamma")}
+	result, err := client.ExportPages("db", opts)
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(pkg.ExportResult{Written: 2}))
+	Expect(filepath.Join(dir, "alpha.md")).NotTo(BeAnExistingFile())
+	data, err := os.ReadFile(filepath.Join(dir, "beta.md"))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(ContainSubstring("Body of a"))
+	Expect(filepath.Join(dir, "gamma.md")).To(BeAnExistingFile())
+
+	listed = []notion.Page{page("a", "Same"), page("b", "Same")}
+	_, err = client.ExportPages("db", opts)
+	Expect(err).To(MatchError("pages a and b both export to same.md: add .ShortID to the filename template"))
+}
+
+func TestSlugify(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(pkg.Slugify("Kubernetes Networking: A Primer")).To(Equal("kubernetes-networking-a-primer"))
+	Expect(pkg.Slugify("???")).To(Equal("untitled"))
+}
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dstotijn/go-notion"
+)
+
+// TagColumn is the multi-select column holding the page tags.
+const TagColumn = "Tags"
+
+// MaxFilterDepth is the number of compound filter levels Notion accepts in a query.
+const MaxFilterDepth = 2
+
+// UntaggedFilter matches pages without any value in the multi-select column.
+func UntaggedFilter(column string) *notion.DatabaseQueryFilter {
+	return &notion.DatabaseQueryFilter{
+		Property: column,
+		DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+			MultiSelect: &notion.MultiSelectDatabaseQueryFilter{
+				IsEmpty: true,
+			},
+		},
+	}
+}
+
+// TaggedFilter matches pages with at least one value in the multi-select column.
+func TaggedFilter(column string) *notion.DatabaseQueryFilter {
+	return &notion.DatabaseQueryFilter{
+		Property: column,
+		DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+			MultiSelect: &notion.MultiSelectDatabaseQueryFilter{
+				IsNotEmpty: true,
+			},
+		},
+	}
+}
+
+// HasTagFilter matches pages having tag selected in the multi-select column.
+func HasTagFilter(column, tag string) *notion.DatabaseQueryFilter {
+	return &notion.DatabaseQueryFilter{
+		Property: column,
+		DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+			MultiSelect: &notion.MultiSelectDatabaseQueryFilter{
+				Contains: tag,
+			},
+		},
+	}
+}
+
+// And combines filters into one matching all of them. Nil filters are ignored,
+// and nil is returned when there is nothing to filter on.
+func And(filters ...*notion.DatabaseQueryFilter) *notion.DatabaseQueryFilter {
+	children := compound(filters, func(f *notion.DatabaseQueryFilter) []notion.DatabaseQueryFilter {
+		return f.And
+	})
+	if len(children) < 2 {
+		return single(children)
+	}
+	return &notion.DatabaseQueryFilter{And: children}
+}
+
+// Or combines filters into one matching any of them. Nil filters are ignored,
+// and nil is returned when there is nothing to filter on.
+func Or(filters ...*notion.DatabaseQueryFilter) *notion.DatabaseQueryFilter {
+	children := compound(filters, func(f *notion.DatabaseQueryFilter) []notion.DatabaseQueryFilter {
+		return f.Or
+	})
+	if len(children) < 2 {
+		return single(children)
+	}
+	return &notion.DatabaseQueryFilter{Or: children}
+}
+
+// compound collects the non-nil filters, splicing in the children of filters of the same kind,
+// as Notion only allows two levels of nesting.
+func compound(filters []*notion.DatabaseQueryFilter, same func(*notion.DatabaseQueryFilter) []notion.DatabaseQueryFilter) []notion.DatabaseQueryFilter {
+	var children []notion.DatabaseQueryFilter
+	for _, f := range filters {
+		switch {
+		case f == nil:
+		case f.Property == "" && len(f.And)+len(f.Or) > 0 && len(same(f)) > 0:
+			children = append(children, same(f)...)
+		default:
+			children = append(children, *f)
+		}
+	}
+	return children
+}
+
+func single(children []notion.DatabaseQueryFilter) *notion.DatabaseQueryFilter {
+	if len(children) == 0 {
+		return nil
+	}
+	return &children[0]
+}
+
+// CheckFilterDepth returns an error naming the first compound filter nested
+// deeper than MaxFilterDepth, which Notion rejects.
+func CheckFilterDepth(filter *notion.DatabaseQueryFilter) error {
+	if filter == nil {
+		return nil
+	}
+	return checkDepth(*filter, 1)
+}
+
+func checkDepth(f notion.DatabaseQueryFilter, depth int) error {
+	children := compoundChildren(f)
+	if len(children) == 0 {
+		return nil
+	}
+	if depth > MaxFilterDepth {
+		return fmt.Errorf("%s is nested %d levels deep, Notion allows at most %d", describeFilter(f), depth, MaxFilterDepth)
+	}
+	for _, child := range children {
+		if err := checkDepth(child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compoundChildren(f notion.DatabaseQueryFilter) []notion.DatabaseQueryFilter {
+	if len(f.Or) > 0 {
+		return f.Or
+	}
+	return f.And
+}
+
+// describeFilter renders a filter as its property names joined by and and or.
+func describeFilter(f notion.DatabaseQueryFilter) string {
+	children := compoundChildren(f)
+	if len(children) == 0 {
+		if f.Property != "" {
+			return f.Property
+		}
+		return string(f.Timestamp)
+	}
+	separator := " and "
+	if len(f.Or) > 0 {
+		separator = " or "
+	}
+	parts := make([]string, len(children))
+	for i, child := range children {
+		parts[i] = describeFilter(child)
+	}
+	return "(" + strings.Join(parts, separator) + ")"
+}
+
+// TagQuery selects pages by their tags.
+type TagQuery struct {
+	// Untagged selects pages without tags.
+	Untagged bool
+	// Tagged selects pages with at least one tag.
+	Tagged bool
+	// Tags selects pages having all of these tags.
+	Tags []string
+}
+
+// Filter builds the database filter for the query, nil when all pages are selected.
+func (q TagQuery) Filter(column string) (*notion.DatabaseQueryFilter, error) {
+	if q.Untagged && (q.Tagged || len(q.Tags) > 0) {
+		return nil, errors.New("untagged pages can't also be tagged")
+	}
+	if q.Untagged {
+		return UntaggedFilter(column), nil
+	}
+
+	filters := make([]*notion.DatabaseQueryFilter, 0, len(q.Tags)+1)
+	if q.Tagged && len(q.Tags) == 0 {
+		filters = append(filters, TaggedFilter(column))
+	}
+	for _, tag := range q.Tags {
+		filters = append(filters, HasTagFilter(column, tag))
+	}
+	return And(filters...), nil
+}
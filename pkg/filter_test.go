@@ -0,0 +1,56 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	. "github.com/onsi/gomega"
+)
+
+func TestTagQueryFilter(t *testing.T) {
+	RegisterTestingT(t)
+
+	filter, err := pkg.TagQuery{}.Filter(pkg.TagColumn)
+	Expect(err).To(BeNil())
+	Expect(filter).To(BeNil())
+
+	filter, err = pkg.TagQuery{Untagged: true}.Filter(pkg.TagColumn)
+	Expect(err).To(BeNil())
+	Expect(filter).To(Equal(pkg.UntaggedFilter(pkg.TagColumn)))
+
+	filter, err = pkg.TagQuery{Tagged: true}.Filter(pkg.TagColumn)
+	Expect(err).To(BeNil())
+	Expect(filter).To(Equal(pkg.TaggedFilter(pkg.TagColumn)))
+
+	filter, err = pkg.TagQuery{Tags: []string{"go", "k8s"}}.Filter(pkg.TagColumn)
+	Expect(err).To(BeNil())
+	Expect(filter).To(Equal(&notion.DatabaseQueryFilter{
+		And: []notion.DatabaseQueryFilter{
+			*pkg.HasTagFilter(pkg.TagColumn, "go"),
+			*pkg.HasTagFilter(pkg.TagColumn, "k8s"),
+		},
+	}))
+
+	_, err = pkg.TagQuery{Untagged: true, Tags: []string{"go"}}.Filter(pkg.TagColumn)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestOr(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(pkg.Or(nil, nil)).To(BeNil())
+	Expect(pkg.Or(pkg.HasTagFilter("Tags", "go"), nil)).To(Equal(pkg.HasTagFilter("Tags", "go")))
+	Expect(pkg.Or(pkg.HasTagFilter("Tags", "go"), pkg.UntaggedFilter("Tags")).Or).To(HaveLen(2))
+}
+
+func TestCheckFilterDepth(t *testing.T) {
+	RegisterTestingT(t)
+	where, err := pkg.ParseWhere(`Read = true or (Stars > 3 and Status = "x")`, schema)
+	Expect(err).To(BeNil())
+	Expect(pkg.CheckFilterDepth(where)).To(Succeed())
+	Expect(pkg.CheckFilterDepth(nil)).To(Succeed())
+
+	tags, err := pkg.TagQuery{Tags: []string{"a", "b"}}.Filter(pkg.TagColumn)
+	Expect(err).To(BeNil())
+	Expect(pkg.CheckFilterDepth(pkg.And(tags, where))).To(MatchError("(Stars and Status) is nested 3 levels deep, Notion allows at most 2"))
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is sent when the request has no token limit, as the messages API requires one.
+	anthropicMaxTokens = 1024
+)
+
+// AnthropicClient serves chat completions from the Anthropic messages API.
+type AnthropicClient struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+var _ OpenAIClient = (*AnthropicClient)(nil)
+
+type anthropicRequest struct {
+	Model      string             `json:"model"`
+	MaxTokens  int                `json:"max_tokens"`
+	System     string             `json:"system,omitempty"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice map[string]string  `json:"tool_choice,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// CreateChatCompletion implements OpenAIClient with the /v1/messages endpoint.
+// System messages become the system prompt, and tools are translated both ways.
+func (c *AnthropicClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	body := anthropicRequest{Model: req.Model, MaxTokens: req.MaxTokens}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = anthropicMaxTokens
+	}
+	var system []string
+	for _, message := range req.Messages {
+		if message.Role == openai.ChatMessageRoleSystem {
+			system = append(system, message.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, anthropicMessage{Role: message.Role, Content: message.Content})
+	}
+	body.System = strings.Join(system, "\n\n")
+	for _, tool := range req.Tools {
+		if tool.Function != nil {
+			body.Tools = append(body.Tools, anthropicTool{Name: tool.Function.Name, Description: tool.Function.Description, InputSchema: tool.Function.Parameters})
+		}
+	}
+	if choice, ok := req.ToolChoice.(openai.ToolChoice); ok {
+		body.ToolChoice = map[string]string{"type": "tool", "name": choice.Function.Name}
+	}
+
+	header := http.Header{}
+	header.Set("x-api-key", c.APIKey)
+	header.Set("anthropic-version", anthropicVersion)
+	var resp anthropicResponse
+	err := postJSON(ctx, c.HTTPClient, c.BaseURL+"/v1/messages", header, body, &resp, func(data []byte) (string, string) {
+		var e struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return e.Error.Message, e.Error.Type
+	})
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var text []string
+	for _, content := range resp.Content {
+		switch content.Type {
+		case "text":
+			text = append(text, content.Text)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+				ID:       content.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: content.Name, Arguments: string(content.Input)},
+			})
+		}
+	}
+	message.Content = strings.Join(text, "")
+
+	finishReason := openai.FinishReasonStop
+	switch resp.StopReason {
+	case "max_tokens":
+		finishReason = openai.FinishReasonLength
+	case "tool_use":
+		finishReason = openai.FinishReasonToolCalls
+	}
+	return openai.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Model:   resp.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}},
+		Usage: openai.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}, nil
+}
@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// EmbeddingClient creates embeddings with the OpenAI embeddings API, or a translation of it.
+type EmbeddingClient interface {
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+var (
+	_ EmbeddingClient = (*openai.Client)(nil)
+	_ EmbeddingClient = (*OllamaClient)(nil)
+	_ EmbeddingClient = (*AnthropicClient)(nil)
+)
+
+// ErrNoEmbeddings is returned by providers without an embeddings API.
+var ErrNoEmbeddings = errors.New("the provider has no embeddings API")
+
+const (
+	// EmbeddingTokens caps the text embedded for a page, below the input limit of embedding models.
+	EmbeddingTokens = 8000
+	// EmbeddingBatchSize is the number of texts embedded per request.
+	EmbeddingBatchSize = 64
+)
+
+// defaultEmbeddingModels are used when no embedding model is configured for a provider.
+var defaultEmbeddingModels = map[string]string{
+	ProviderOpenAI: string(openai.SmallEmbedding3),
+	ProviderOllama: "nomic-embed-text",
+}
+
+// DefaultEmbeddingModel returns the embedding model used with provider when none is configured, if it has one.
+func DefaultEmbeddingModel(provider string) string {
+	if provider == "" {
+		provider = ProviderOpenAI
+	}
+	return defaultEmbeddingModels[provider]
+}
+
+// EmbeddingText returns the text embedded for a page: its title and the beginning of its content.
+func EmbeddingText(input *TagInput, tok Tokenizer) string {
+	title := trimText(input.Title, maxTitleTokens, tok)
+	content := trimText(input.Raw, EmbeddingTokens-tok.Count(title), tok)
+	return strings.TrimSpace(title + "\n\n" + content)
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// CreateEmbeddings implements EmbeddingClient with Ollama's /api/embed endpoint.
+func (c *OllamaClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	req := conv.Convert()
+	body := ollamaEmbedRequest{Model: string(req.Model)}
+	switch input := req.Input.(type) {
+	case []string:
+		body.Input = input
+	case string:
+		body.Input = []string{input}
+	default:
+		return openai.EmbeddingResponse{}, fmt.Errorf("unsupported embedding input %T", req.Input)
+	}
+
+	var resp ollamaEmbedResponse
+	err := postJSON(ctx, c.HTTPClient, c.BaseURL+"/api/embed", http.Header{}, body, &resp, func(data []byte) (string, string) {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return e.Error, ""
+	})
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	out := openai.EmbeddingResponse{Object: "list", Model: openai.EmbeddingModel(resp.Model)}
+	for i, embedding := range resp.Embeddings {
+		out.Data = append(out.Data, openai.Embedding{Object: "embedding", Embedding: embedding, Index: i})
+	}
+	return out, nil
+}
+
+// CreateEmbeddings implements EmbeddingClient, failing with ErrNoEmbeddings as Anthropic has no embeddings API.
+func (c *AnthropicClient) CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, fmt.Errorf("%w: configure an openai, openai-compatible or ollama provider for embeddings", ErrNoEmbeddings)
+}
+
+// Normalize scales vector to unit length, so that the dot product of normalized vectors
+// is their cosine similarity. The zero vector is returned as is.
+func Normalize(vector []float32) []float32 {
+	var sum float64
+	for _, x := range vector {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	normalized := make([]float32, len(vector))
+	for i, x := range vector {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized
+}
+
+// Dot returns the dot product of two vectors, their cosine similarity when both are
+// normalized, and 0 when their lengths differ.
+func Dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// Cosine returns the cosine similarity of two vectors, 0 when their lengths differ or one is zero.
+func Cosine(a, b []float32) float64 {
+	na, nb := Dot(a, a), Dot(b, b)
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return Dot(a, b) / math.Sqrt(na*nb)
+}
+
+// Centroid is the mean direction of the embeddings of the pages having a tag.
+type Centroid struct {
+	// Pages is the number of pages the centroid was computed from.
+	Pages  int       `json:"pages"`
+	Vector []float32 `json:"vector"`
+}
+
+// Centroids are the centroids of the tags of a database, for one embedding model.
+type Centroids struct {
+	Model      string              `json:"model"`
+	DatabaseID string              `json:"database_id"`
+	TrainedAt  time.Time           `json:"trained_at"`
+	Tags       map[string]Centroid `json:"tags"`
+}
+
+// CentroidTrainer accumulates the embeddings of tagged pages into tag centroids.
+type CentroidTrainer struct {
+	model  string
+	sums   map[string][]float64
+	counts map[string]int
+}
+
+// NewCentroidTrainer returns a trainer for embeddings of model.
+func NewCentroidTrainer(model string) *CentroidTrainer {
+	return &CentroidTrainer{model: model, sums: map[string][]float64{}, counts: map[string]int{}}
+}
+
+// Add counts the embedding of a page towards each of its tags. Embeddings are normalized
+// first, so that long and short pages weigh the same.
+func (t *CentroidTrainer) Add(tags []string, vector []float32) {
+	vector = Normalize(vector)
+	for _, tag := range tags {
+		sum, ok := t.sums[tag]
+		if !ok {
+			sum = make([]float64, len(vector))
+			t.sums[tag] = sum
+		}
+		if len(sum) != len(vector) {
+			continue
+		}
+		for i, x := range vector {
+			sum[i] += float64(x)
+		}
+		t.counts[tag]++
+	}
+}
+
+// Centroids returns the normalized centroids of the tags added so far.
+func (t *CentroidTrainer) Centroids() *Centroids {
+	c := &Centroids{Model: t.model, TrainedAt: time.Now().UTC(), Tags: make(map[string]Centroid, len(t.sums))}
+	for tag, sum := range t.sums {
+		vector := make([]float32, len(sum))
+		for i, x := range sum {
+			vector[i] = float32(x / float64(t.counts[tag]))
+		}
+		c.Tags[tag] = Centroid{Pages: t.counts[tag], Vector: Normalize(vector)}
+	}
+	return c
+}
+
+// TagScore is the similarity of a page to a tag.
+type TagScore struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+// Suggest returns the tags of tagOptions most similar to vector, at most topK of them
+// with a similarity of at least threshold, most similar first. Tags without a centroid
+// are never suggested.
+func (c *Centroids) Suggest(vector []float32, tagOptions []string, topK int, threshold float64) []TagScore {
+	var scores []TagScore
+	for _, tag := range tagOptions {
+		centroid, ok := c.Tags[tag]
+		if !ok {
+			continue
+		}
+		if score := Cosine(vector, centroid.Vector); score >= threshold {
+			scores = append(scores, TagScore{Tag: tag, Score: score})
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	if len(scores) > topK {
+		scores = scores[:topK]
+	}
+	return scores
+}
@@ -0,0 +1,69 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestCosine(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(llm.Cosine([]float32{1, 0}, []float32{2, 0})).To(BeNumerically("~", 1, 1e-9))
+	Expect(llm.Cosine([]float32{1, 0}, []float32{0, 3})).To(BeNumerically("~", 0, 1e-9))
+	Expect(llm.Cosine([]float32{1, 1}, []float32{-1, -1})).To(BeNumerically("~", -1, 1e-9))
+	Expect(llm.Cosine([]float32{1}, []float32{1, 0})).To(BeZero())
+	Expect(llm.Cosine([]float32{0, 0}, []float32{1, 0})).To(BeZero())
+	Expect(llm.Normalize([]float32{3, 4})).To(Equal([]float32{0.6, 0.8}))
+}
+
+func TestCentroids_Suggest(t *testing.T) {
+	RegisterTestingT(t)
+	trainer := llm.NewCentroidTrainer("test-embedding")
+	trainer.Add([]string{"go"}, []float32{1, 0, 0})
+	trainer.Add([]string{"go", "web"}, []float32{10, 10, 0})
+	trainer.Add([]string{"cooking"}, []float32{0, 0, 1})
+	centroids := trainer.Centroids()
+
+	Expect(centroids.Model).To(Equal("test-embedding"))
+	Expect(centroids.Tags["go"].Pages).To(Equal(2))
+	Expect(centroids.Tags["web"].Pages).To(Equal(1))
+
+	scores := centroids.Suggest([]float32{1, 0.2, 0}, []string{"go", "web", "cooking", "untrained"}, 3, 0.5)
+	Expect(scores).To(HaveLen(2))
+	Expect(scores[0].Tag).To(Equal("go"))
+	Expect(scores[1].Tag).To(Equal("web"))
+	Expect(scores[0].Score).To(BeNumerically(">", scores[1].Score))
+
+	Expect(centroids.Suggest([]float32{1, 0.2, 0}, []string{"go", "web"}, 1, 0.5)).To(HaveLen(1))
+	Expect(centroids.Suggest([]float32{1, 0.2, 0}, []string{"cooking"}, 3, 0.5)).To(BeEmpty())
+}
+
+func TestOllamaClient_CreateEmbeddings(t *testing.T) {
+	RegisterTestingT(t)
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/api/embed", http.StatusOK, `{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]]}`, &body, &header)
+
+	client := &llm.OllamaClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	resp, err := client.CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{"first", "second"},
+		Model: "nomic-embed-text",
+	})
+	Expect(err).To(BeNil())
+	Expect(body).To(Equal(map[string]any{"model": "nomic-embed-text", "input": []any{"first", "second"}}))
+	Expect(resp.Data).To(HaveLen(2))
+	Expect(resp.Data[1]).To(Equal(openai.Embedding{Object: "embedding", Embedding: []float32{0.3, 0.4}, Index: 1}))
+}
+
+func TestAnthropicClient_CreateEmbeddings(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := (&llm.AnthropicClient{}).CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{Input: []string{"text"}})
+	Expect(errors.Is(err, llm.ErrNoEmbeddings)).To(BeTrue())
+}
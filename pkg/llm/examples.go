@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// RankExamples orders the examples by relevance to the page, most relevant first.
+// Relevance is the sum of the inverse document frequencies of the words the page shares
+// with the example's title, so rare words like product names weigh more than common ones.
+// Examples sharing no word keep their order after the others.
+func RankExamples(input *TagInput, examples []Example) []Example {
+	docs := make([]map[string]bool, len(examples))
+	frequency := map[string]int{}
+	for i, example := range examples {
+		docs[i] = words(example.Title + " " + strings.Join(example.Tags, " "))
+		for word := range docs[i] {
+			frequency[word]++
+		}
+	}
+	page := words(input.Title + " " + input.Raw)
+
+	scores := make([]float64, len(examples))
+	for i, doc := range docs {
+		for word := range doc {
+			if page[word] {
+				scores[i] += math.Log(1 + float64(len(examples))/float64(frequency[word]))
+			}
+		}
+	}
+	order := make([]int, len(examples))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	ranked := make([]Example, len(examples))
+	for i, j := range order {
+		ranked[i] = examples[j]
+	}
+	return ranked
+}
+
+// words returns the distinct lower-case words of text, ignoring words under three letters.
+func words(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 {
+			set[word] = true
+		}
+	}
+	return set
+}
+
+// ExampleMessages renders examples as pairs of a user message describing the page and
+// an assistant message answering with its tags, in the order given, while they fit in
+// budget tokens. The content of each example is trimmed to contentTokens. It returns
+// the messages, the examples they show and the tokens they use.
+func (p *Prompts) ExampleMessages(examples []Example, budget, contentTokens int, tok Tokenizer) ([]openai.ChatCompletionMessage, []Example, int, error) {
+	var messages []openai.ChatCompletionMessage
+	var shown []Example
+	used := 0
+	for _, example := range examples {
+		input := TagInput{Title: example.Title, URL: example.URL}
+		input = capTagInput(&input, tok)
+		input.Raw = trimText(example.Content, contentTokens, tok)
+		user, err := p.RenderTagInput(&input)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		answer := strings.Join(example.Tags, ", ")
+		tokens := tok.Count(user) + tok.Count(answer) + 2*MessageOverhead
+		if used+tokens > budget {
+			continue
+		}
+		used += tokens
+		shown = append(shown, example)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: user},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer},
+		)
+	}
+	return messages, shown, used, nil
+}
@@ -0,0 +1,60 @@
+package llm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRankExamples(t *testing.T) {
+	RegisterTestingT(t)
+	examples := []llm.Example{
+		{ID: "1", Title: "Weekly groceries"},
+		{ID: "2", Title: "Go generics in practice", Tags: []string{"go"}},
+		{ID: "3", Title: "Kubernetes networking with Cilium", Tags: []string{"kubernetes"}},
+		{ID: "4", Title: "Notes on networking"},
+	}
+	input := &llm.TagInput{Title: "Debugging Cilium", Raw: "Pod networking broke after the upgrade."}
+
+	var ids []string
+	for _, example := range llm.RankExamples(input, examples) {
+		ids = append(ids, example.ID)
+	}
+	Expect(ids).To(Equal([]string{"3", "4", "1", "2"}))
+}
+
+func TestExampleMessages(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.HeuristicTokenizer{}
+	examples := []llm.Example{
+		{Title: "Go generics", URL: "https://example.com/1", Content: "Type parameters in Go. More text that is cut.", Tags: []string{"go", "programming"}},
+		{Title: "Long page", Content: strings.Repeat("word ", 1000), Tags: []string{"misc"}},
+		{Title: "Cilium", Tags: []string{"kubernetes"}},
+	}
+
+	messages, shown, used, err := llm.DefaultPrompts().ExampleMessages(examples, 110, 6, tok)
+	Expect(err).To(BeNil())
+	Expect(shown).To(HaveLen(3))
+	Expect(messages).To(HaveLen(6))
+	Expect(messages[0].Role).To(Equal(openai.ChatMessageRoleUser))
+	Expect(messages[0].Content).To(ContainSubstring("Content Raw: Type parameters in Go."))
+	Expect(messages[0].Content).NotTo(ContainSubstring("cut"))
+	Expect(messages[1]).To(Equal(openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "go, programming"}))
+	Expect(used).To(Equal(107))
+
+	// the long page doesn't fit next to the first one, the last one still does
+	_, shown, used, err = llm.DefaultPrompts().ExampleMessages(examples, 75, 6, tok)
+	Expect(err).To(BeNil())
+	Expect(shown).To(HaveLen(2))
+	Expect(shown[1].Title).To(Equal("Cilium"))
+	Expect(used).To(Equal(73))
+
+	messages, shown, used, err = llm.DefaultPrompts().ExampleMessages(examples, 0, 6, tok)
+	Expect(err).To(BeNil())
+	Expect(messages).To(BeEmpty())
+	Expect(shown).To(BeEmpty())
+	Expect(used).To(BeZero())
+}
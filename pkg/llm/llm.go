@@ -1,15 +1,13 @@
 package llm
 
 import (
-	"bytes"
 	"context"
 	"strings"
-	"text/template"
 
 	"github.com/sashabaranov/go-openai"
 )
 
-//go:generate mockgen -destination=../mocks/mock_llm.go -package=mocks . LLMClient,OpenAIClient
+//go:generate mockgen -destination=../mocks/mock_llm.go -package=mocks . LLMClient,OpenAIClient,EmbeddingClient
 type OpenAIClient interface {
 	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
 }
@@ -20,13 +18,18 @@ type LLMClient interface {
 	OpenAIClient
 }
 
+// The built-in prompt templates, see PromptConfig to replace them.
 const (
 	SystemPromptTemplate = `
 		You are a command-line app that responds with only a list of tags that categorize the content of the messages being sent to you.
-		You can only provide AT MOST 3 tags, and at least 1 TAG.  Less is preferable.  Do not infer tags. The list of tags you output are:
+		You can only provide AT MOST {{.MaxTags}} tags, and at least 1 TAG.  Less is preferable.  Do not infer tags. The list of tags you output are:
 
-		{{- range .}}
-		- {{.}}
+		{{- range .Tags}}
+		- {{.Name}}
+		{{- with .Description}}: {{sentence .}}{{end}}
+		{{- with .Synonyms}} Also called: {{join . ", "}}.{{end}}
+		{{- with .Examples}} For example: {{join . "; "}}.{{end}}
+		{{- with .NeverUseWhen}} Never use when: {{sentence .}}{{end}}
 		{{- end}}
 	`
 
@@ -42,38 +45,24 @@ type TagInput struct {
 	Title string `json:"title"`
 	URL   string `json:"url"`
 	Raw   string `json:"raw"`
+	// Properties holds the plain text values of the page properties, by name.
+	Properties map[string]string `json:"properties,omitempty"`
 }
 
-func GenerateSystemPrompt(tags []string) string {
-	tmpl, err := template.New("system-prompt").Parse(SystemPromptTemplate)
+// GenerateSystemPrompt renders the built-in system prompt for a vocabulary of tag names,
+// described with meta when it is not nil.
+func GenerateSystemPrompt(tags []string, meta TagMetadata) string {
+	prompt, err := DefaultPrompts().RenderSystem(NewPromptData(tags, meta))
 	if err != nil {
 		panic(err)
 	}
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, tags)
-	if err != nil {
-		panic(err)
-	}
-	return buf.String()
+	return prompt
 }
 
+// GenerateTagInputMessage renders the tag input, trimming its content to about tokenLimit
+// tokens. Use FitTagInput to count tokens with the model's tokenizer.
 func GenerateTagInputMessage(input *TagInput, tokenLimit int) string {
-	tmpl, err := template.New("tag-input").Parse(TagInputTemplate)
-	if err != nil {
-		panic(err)
-	}
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, input)
-	if err != nil {
-		panic(err)
-	}
-
-	// Truncate the message to the token limit
-	message := buf.String()
-	if len(message) > tokenLimit {
-		message = message[:tokenLimit]
-	}
-
+	message, _ := FitTagInput(input, tokenLimit, HeuristicTokenizer{})
 	return message
 }
 
@@ -17,7 +17,7 @@ func TestGenerateSystemPrompt(t *testing.T) {
 		- tag2
 		- tag3
 	`
-	result := llm.GenerateSystemPrompt(tags)
+	result := llm.GenerateSystemPrompt(tags, nil)
 	Expect(result).To(Equal(expected))
 }
 
@@ -38,19 +38,20 @@ func TestGenerateTagInputMessage(t *testing.T) {
 }
 
 func TestGenerateTagInputMessage_Truncate(t *testing.T) {
+	RegisterTestingT(t)
 	input := &llm.TagInput{
 		Title: "Test Title",
 		URL:   "http://example.com",
-		Raw:   "Test content",
+		Raw:   "Test content. More test content that doesn't fit.",
 	}
 	expected := `
 		Title: Test Title
 		URL: http://example.com
 
-		Content Raw: Test content
+		Content Raw: Test content.
 	`
-	result := llm.GenerateTagInputMessage(input, 10)
-	Expect(result).To(Equal(expected[:10]))
+	result := llm.GenerateTagInputMessage(input, 21)
+	Expect(result).To(Equal(expected))
 }
 
 func TestSplitResponse(t *testing.T) {
@@ -59,3 +60,24 @@ func TestSplitResponse(t *testing.T) {
 	result := llm.SplitResponse(response)
 	Expect(result).To(Equal(expected))
 }
+
+func TestGenerateSystemPrompt_Metadata(t *testing.T) {
+	RegisterTestingT(t)
+	meta := llm.TagMetadata{
+		"ops": {
+			Description:  "Running services in production",
+			Synonyms:     []string{"devops", "sre"},
+			Examples:     []string{"on-call runbooks", "incident reviews"},
+			NeverUseWhen: "the page is about writing code",
+		},
+		"Reading": {Description: "Books and articles to read later"},
+	}
+	expected := `
+		You are a command-line app that responds with only a list of tags that categorize the content of the messages being sent to you.
+		You can only provide AT MOST 3 tags, and at least 1 TAG.  Less is preferable.  Do not infer tags. The list of tags you output are:
+		- ops: Running services in production. Also called: devops, sre. For example: on-call runbooks; incident reviews. Never use when: the page is about writing code.
+		- reading: Books and articles to read later.
+		- go
+	`
+	Expect(llm.GenerateSystemPrompt([]string{"ops", "reading", "go"}, meta)).To(Equal(expected))
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ModelInfo holds the token limits of a model.
+type ModelInfo struct {
+	// ContextWindow is the number of tokens of the prompt and the answer together.
+	ContextWindow int `yaml:"context_window"`
+	// MaxOutputTokens is the most tokens the model answers with.
+	MaxOutputTokens int `yaml:"max_output_tokens"`
+}
+
+// models are the built-in models. Others can be described in the config file.
+var models = map[string]ModelInfo{
+	openai.GPT4o:                 {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4o20240513:         {ContextWindow: 128000, MaxOutputTokens: 4096},
+	"gpt-4o-mini":                {ContextWindow: 128000, MaxOutputTokens: 16384},
+	openai.GPT4Turbo:             {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4Turbo20240409:     {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4TurboPreview:      {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4Turbo0125:         {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4Turbo1106:         {ContextWindow: 128000, MaxOutputTokens: 4096},
+	openai.GPT4:                  {ContextWindow: 8192, MaxOutputTokens: 4096},
+	openai.GPT432K:               {ContextWindow: 32768, MaxOutputTokens: 4096},
+	openai.GPT3Dot5Turbo:         {ContextWindow: 16385, MaxOutputTokens: 4096},
+	"claude-3-5-sonnet-20240620": {ContextWindow: 200000, MaxOutputTokens: 8192},
+	"claude-3-opus-20240229":     {ContextWindow: 200000, MaxOutputTokens: 4096},
+	"claude-3-sonnet-20240229":   {ContextWindow: 200000, MaxOutputTokens: 4096},
+	"claude-3-haiku-20240307":    {ContextWindow: 200000, MaxOutputTokens: 4096},
+}
+
+// defaultModels are used when no model is configured for a provider.
+var defaultModels = map[string]string{
+	ProviderOpenAI:    openai.GPT4o,
+	ProviderAnthropic: "claude-3-5-sonnet-20240620",
+}
+
+// DefaultModel returns the model used with provider when none is configured, if it has one.
+func DefaultModel(provider string) string {
+	if provider == "" {
+		provider = ProviderOpenAI
+	}
+	return defaultModels[provider]
+}
+
+// KnownModels returns the names of the built-in models, sorted.
+func KnownModels() []string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupModel returns the limits of a model, from custom first and then the built-in models.
+// It fails for unknown models and models without limits, which would truncate every prompt.
+func LookupModel(name string, custom map[string]ModelInfo) (ModelInfo, error) {
+	if name == "" {
+		return ModelInfo{}, fmt.Errorf("no model configured: use --model or set model in the config file")
+	}
+	info, ok := custom[name]
+	if !ok {
+		info, ok = models[name]
+	}
+	if !ok {
+		return ModelInfo{}, fmt.Errorf("unknown model %q: describe its context_window and max_output_tokens under models in the config file", name)
+	}
+	if info.ContextWindow <= 0 || info.MaxOutputTokens <= 0 {
+		return ModelInfo{}, fmt.Errorf("model %q needs a context_window and max_output_tokens above zero", name)
+	}
+	if info.MaxOutputTokens >= info.ContextWindow {
+		return ModelInfo{}, fmt.Errorf("model %q has max_output_tokens %d, which leaves no room for the prompt in its context window of %d", name, info.MaxOutputTokens, info.ContextWindow)
+	}
+	return info, nil
+}
@@ -0,0 +1,49 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestLookupModel(t *testing.T) {
+	RegisterTestingT(t)
+
+	info, err := llm.LookupModel(openai.GPT4TurboPreview, nil)
+	Expect(err).To(BeNil())
+	Expect(info).To(Equal(llm.ModelInfo{ContextWindow: 128000, MaxOutputTokens: 4096}))
+
+	custom := map[string]llm.ModelInfo{
+		"llama3.1":       {ContextWindow: 8192, MaxOutputTokens: 1024},
+		openai.GPT4o:     {ContextWindow: 128000, MaxOutputTokens: 16384},
+		"no-limits":      {},
+		"no-room-prompt": {ContextWindow: 1024, MaxOutputTokens: 1024},
+	}
+	info, err = llm.LookupModel("llama3.1", custom)
+	Expect(err).To(BeNil())
+	Expect(info.ContextWindow).To(Equal(8192))
+	info, err = llm.LookupModel(openai.GPT4o, custom)
+	Expect(err).To(BeNil())
+	Expect(info.MaxOutputTokens).To(Equal(16384))
+
+	_, err = llm.LookupModel("llama3.1", nil)
+	Expect(err).To(MatchError(ContainSubstring(`unknown model "llama3.1"`)))
+	_, err = llm.LookupModel("no-limits", custom)
+	Expect(err).To(MatchError(ContainSubstring("above zero")))
+	_, err = llm.LookupModel("no-room-prompt", custom)
+	Expect(err).To(MatchError(ContainSubstring("no room for the prompt")))
+	_, err = llm.LookupModel("", nil)
+	Expect(err).To(MatchError(ContainSubstring("no model configured")))
+}
+
+func TestDefaultModel(t *testing.T) {
+	RegisterTestingT(t)
+	for _, provider := range []string{"", llm.ProviderOpenAI, llm.ProviderAnthropic} {
+		_, err := llm.LookupModel(llm.DefaultModel(provider), nil)
+		Expect(err).To(BeNil())
+	}
+	Expect(llm.DefaultModel(llm.ProviderOllama)).To(BeEmpty())
+	Expect(llm.KnownModels()).To(ContainElement(openai.GPT4o))
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OllamaClient serves chat completions from the native API of an Ollama server.
+type OllamaClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+var _ OpenAIClient = (*OllamaClient)(nil)
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openai.Tool   `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// CreateChatCompletion implements OpenAIClient with Ollama's /api/chat endpoint.
+// Ollama can't be forced to call a tool, so the tool choice is ignored.
+func (c *OllamaClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	body := ollamaChatRequest{Model: req.Model, Tools: req.Tools}
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, ollamaMessage{Role: message.Role, Content: message.Content})
+	}
+	if req.MaxTokens > 0 {
+		body.Options = map[string]any{"num_predict": req.MaxTokens}
+	}
+
+	var resp ollamaChatResponse
+	err := postJSON(ctx, c.HTTPClient, c.BaseURL+"/api/chat", http.Header{}, body, &resp, func(data []byte) (string, string) {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return e.Error, ""
+	})
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: resp.Message.Content}
+	for _, call := range resp.Message.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: call.Function.Name, Arguments: string(call.Function.Arguments)},
+		})
+	}
+	finishReason := openai.FinishReasonStop
+	if resp.DoneReason == "length" {
+		finishReason = openai.FinishReasonLength
+	}
+	return openai.ChatCompletionResponse{
+		Object:  "chat.completion",
+		Created: resp.CreatedAt.Unix(),
+		Model:   resp.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}},
+		Usage: openai.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}, nil
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// PromptConfig overrides the built-in prompt templates, inline or from files.
+// Relative file paths are resolved against the directory of the config file.
+type PromptConfig struct {
+	// System is the template of the system prompt, rendered with PromptData.
+	System string `yaml:"system"`
+	// SystemFile is a file holding the system prompt template.
+	SystemFile string `yaml:"system_file"`
+	// TagInput is the template of the message describing the page, rendered with a TagInput.
+	TagInput string `yaml:"tag_input"`
+	// TagInputFile is a file holding the tag input template.
+	TagInputFile string `yaml:"tag_input_file"`
+}
+
+// TagInfo describes a tag of the vocabulary. It prints as its name in templates.
+type TagInfo struct {
+	Name string `json:"name" yaml:"-"`
+	// Description says what the tag is about.
+	Description string `json:"description" yaml:"description,omitempty"`
+	// Synonyms are other names of the tag, accepted in place of it.
+	Synonyms []string `json:"synonyms" yaml:"synonyms,omitempty"`
+	// Examples are topics or titles the tag fits.
+	Examples []string `json:"examples" yaml:"examples,omitempty"`
+	// NeverUseWhen tells when the tag must not be used, even if it seems to fit.
+	NeverUseWhen string `json:"never_use_when" yaml:"never_use_when,omitempty"`
+}
+
+func (t TagInfo) String() string {
+	return t.Name
+}
+
+// Example is a page tagged by hand, shown to the model as an example.
+type Example struct {
+	// ID is the ID of the page.
+	ID    string
+	Title string
+	URL   string
+	// Content is the page content, shortened when rendered.
+	Content string
+	Tags    []string
+}
+
+// PromptData is available to system prompt templates.
+type PromptData struct {
+	// Tags is the vocabulary the model chooses from.
+	Tags []TagInfo
+	// MaxTags is the most tags the model may choose.
+	MaxTags int
+	// Page is the page being tagged, without its content.
+	Page TagInput
+	// Examples are pages tagged by hand.
+	Examples []Example
+}
+
+// NewPromptData returns the prompt data for a vocabulary of tag names, described with meta.
+func NewPromptData(tags []string, meta TagMetadata) PromptData {
+	return PromptData{Tags: meta.Describe(tags), MaxTags: MaxTags}
+}
+
+// Prompts holds the parsed prompt templates.
+type Prompts struct {
+	System   *template.Template
+	TagInput *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"sentence": sentence,
+}
+
+// sentence ends text with a period unless it already ends with punctuation.
+func sentence(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text[len(text)-1:], ".!?") {
+		return text
+	}
+	return text + "."
+}
+
+var defaultPrompts = mustParsePrompts(SystemPromptTemplate, TagInputTemplate)
+
+// DefaultPrompts returns the built-in prompt templates.
+func DefaultPrompts() *Prompts {
+	return defaultPrompts
+}
+
+func mustParsePrompts(system, tagInput string) *Prompts {
+	prompts, err := parsePrompts(system, tagInput)
+	if err != nil {
+		panic(err)
+	}
+	return prompts
+}
+
+func parsePrompts(system, tagInput string) (*Prompts, error) {
+	systemTmpl, err := template.New("system-prompt").Funcs(templateFuncs).Option("missingkey=zero").Parse(system)
+	if err != nil {
+		return nil, fmt.Errorf("invalid system prompt template: %w", err)
+	}
+	tagInputTmpl, err := template.New("tag-input").Funcs(templateFuncs).Option("missingkey=zero").Parse(tagInput)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag input template: %w", err)
+	}
+	return &Prompts{System: systemTmpl, TagInput: tagInputTmpl}, nil
+}
+
+// LoadPrompts parses the configured templates, falling back to the built-in ones, and
+// checks that they render with sample data, so mistakes show before any page is tagged.
+func LoadPrompts(cfg PromptConfig, dir string) (*Prompts, error) {
+	system, err := promptSource(cfg.System, cfg.SystemFile, dir, SystemPromptTemplate)
+	if err != nil {
+		return nil, err
+	}
+	tagInput, err := promptSource(cfg.TagInput, cfg.TagInputFile, dir, TagInputTemplate)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := parsePrompts(system, tagInput)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &TagInput{Title: "Title", URL: "https://example.com", Raw: "Content", Properties: map[string]string{"Name": "Title"}}
+	data := NewPromptData([]string{"tag"}, TagMetadata{"tag": {
+		Description:  "Description",
+		Synonyms:     []string{"synonym"},
+		Examples:     []string{"example"},
+		NeverUseWhen: "never",
+	}})
+	data.Page = *sample
+	data.Examples = []Example{{ID: "id", Title: "Example", URL: "https://example.com", Content: "Content", Tags: []string{"tag"}}}
+	if _, err := prompts.RenderSystem(data); err != nil {
+		return nil, err
+	}
+	if _, err := prompts.RenderTagInput(sample); err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+func promptSource(inline, file, dir, fallback string) (string, error) {
+	if inline != "" && file != "" {
+		return "", fmt.Errorf("prompt template %s is set both inline and as a file", file)
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		return string(data), nil
+	}
+	if inline != "" {
+		return inline, nil
+	}
+	return fallback, nil
+}
+
+// RenderSystem renders the system prompt.
+func (p *Prompts) RenderSystem(data PromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := p.System.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render system prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// RenderTagInput renders the message describing the page.
+func (p *Prompts) RenderTagInput(input *TagInput) (string, error) {
+	var buf bytes.Buffer
+	if err := p.TagInput.Execute(&buf, input); err != nil {
+		return "", fmt.Errorf("failed to render tag input: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,59 @@
+package llm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadPrompts_Defaults(t *testing.T) {
+	RegisterTestingT(t)
+
+	prompts, err := llm.LoadPrompts(llm.PromptConfig{}, "")
+	Expect(err).To(BeNil())
+	system, err := prompts.RenderSystem(llm.NewPromptData([]string{"go", "rust"}, nil))
+	Expect(err).To(BeNil())
+	Expect(system).To(Equal(llm.GenerateSystemPrompt([]string{"go", "rust"}, nil)))
+}
+
+func TestLoadPrompts_Custom(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	system := "Pick up to {{.MaxTags}} of:{{range .Tags}} {{lower .Name}}{{end}}. Status: {{.Page.Properties.Status}}"
+	Expect(os.WriteFile(filepath.Join(dir, "system.tmpl"), []byte(system), 0o600)).To(Succeed())
+
+	prompts, err := llm.LoadPrompts(llm.PromptConfig{
+		SystemFile: "system.tmpl",
+		TagInput:   "{{.Title}} ({{.Properties.Source}}): {{.Raw}}",
+	}, dir)
+	Expect(err).To(BeNil())
+
+	data := llm.NewPromptData([]string{"Go", "Rust"}, nil)
+	data.Page = llm.TagInput{Title: "Generics", Properties: map[string]string{"Status": "Inbox"}}
+	rendered, err := prompts.RenderSystem(data)
+	Expect(err).To(BeNil())
+	Expect(rendered).To(Equal("Pick up to 3 of: go rust. Status: Inbox"))
+
+	rendered, err = prompts.RenderTagInput(&llm.TagInput{Title: "Generics", Raw: "Type parameters.", Properties: map[string]string{"Source": "blog"}})
+	Expect(err).To(BeNil())
+	Expect(rendered).To(Equal("Generics (blog): Type parameters."))
+}
+
+func TestLoadPrompts_Invalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := llm.LoadPrompts(llm.PromptConfig{System: "{{range .Tags}}"}, "")
+	Expect(err).To(MatchError(ContainSubstring("invalid system prompt template")))
+
+	_, err = llm.LoadPrompts(llm.PromptConfig{TagInput: "{{.Missing.Field}}"}, "")
+	Expect(err).To(MatchError(ContainSubstring("failed to render tag input")))
+
+	_, err = llm.LoadPrompts(llm.PromptConfig{SystemFile: "missing.tmpl"}, t.TempDir())
+	Expect(err).To(MatchError(ContainSubstring("failed to read prompt template")))
+
+	_, err = llm.LoadPrompts(llm.PromptConfig{System: "inline", SystemFile: "system.tmpl"}, "")
+	Expect(err).To(MatchError(ContainSubstring("both inline and as a file")))
+}
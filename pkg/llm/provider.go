@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Providers that can serve chat completions.
+const (
+	// ProviderOpenAI is the OpenAI API, at OPENAI_API_BASE when it is set.
+	ProviderOpenAI = "openai"
+	// ProviderOpenAICompatible is any server implementing the OpenAI chat completions API,
+	// such as a llama.cpp server or vLLM. The API key is optional.
+	ProviderOpenAICompatible = "openai-compatible"
+	// ProviderOllama is the native API of an Ollama server.
+	ProviderOllama = "ollama"
+	// ProviderAnthropic is the Anthropic messages API.
+	ProviderAnthropic = "anthropic"
+)
+
+// Providers lists the accepted provider names.
+var Providers = []string{ProviderOpenAI, ProviderOpenAICompatible, ProviderOllama, ProviderAnthropic}
+
+// ProviderConfig selects the backend serving chat completions. All providers are
+// used through the OpenAIClient interface, translating to and from their native APIs.
+type ProviderConfig struct {
+	// Provider is one of Providers, openai by default.
+	Provider string `yaml:"provider"`
+	// BaseURL overrides the default API location of the provider.
+	BaseURL string `yaml:"base_url"`
+	// APIKeyEnv names the environment variable holding the API key, when the default
+	// of the provider doesn't fit.
+	APIKeyEnv string `yaml:"api_key_env"`
+}
+
+// WithDefaults fills in the provider, its base URL and its API key variable.
+func (c ProviderConfig) WithDefaults() ProviderConfig {
+	if c.Provider == "" {
+		c.Provider = ProviderOpenAI
+	}
+	switch c.Provider {
+	case ProviderOpenAI, ProviderOpenAICompatible:
+		if c.BaseURL == "" {
+			c.BaseURL = os.Getenv("OPENAI_API_BASE")
+		}
+		if c.APIKeyEnv == "" {
+			c.APIKeyEnv = "OPENAI_API_KEY"
+		}
+	case ProviderOllama:
+		if c.BaseURL == "" {
+			c.BaseURL = os.Getenv("OLLAMA_HOST")
+		}
+		if c.BaseURL == "" {
+			c.BaseURL = "http://localhost:11434"
+		}
+		if !strings.Contains(c.BaseURL, "://") {
+			c.BaseURL = "http://" + c.BaseURL
+		}
+	case ProviderAnthropic:
+		if c.BaseURL == "" {
+			c.BaseURL = "https://api.anthropic.com"
+		}
+		if c.APIKeyEnv == "" {
+			c.APIKeyEnv = "ANTHROPIC_API_KEY"
+		}
+	}
+	return c
+}
+
+// RequiresAPIKey reports whether the provider can't be used without an API key.
+func (c ProviderConfig) RequiresAPIKey() bool {
+	c = c.WithDefaults()
+	return c.Provider == ProviderOpenAI || c.Provider == ProviderAnthropic
+}
+
+// APIKey reads the API key of the provider from the environment.
+func (c ProviderConfig) APIKey() string {
+	c = c.WithDefaults()
+	if c.APIKeyEnv == "" {
+		return ""
+	}
+	return os.Getenv(c.APIKeyEnv)
+}
+
+// NewProvider returns a client for the configured provider, sending its requests through httpClient.
+func NewProvider(cfg ProviderConfig, httpClient *http.Client) (OpenAIClient, error) {
+	cfg = cfg.WithDefaults()
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	switch cfg.Provider {
+	case ProviderOpenAI, ProviderOpenAICompatible:
+		config := openai.DefaultConfig(cfg.APIKey())
+		if cfg.BaseURL != "" {
+			config.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+		}
+		config.HTTPClient = httpClient
+		return openai.NewClientWithConfig(config), nil
+	case ProviderOllama:
+		return &OllamaClient{BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"), HTTPClient: httpClient}, nil
+	case ProviderAnthropic:
+		return &AnthropicClient{BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"), APIKey: cfg.APIKey(), HTTPClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q, use one of %s", cfg.Provider, strings.Join(Providers, ", "))
+	}
+}
+
+// postJSON sends body to url and decodes the response into out. Failed requests are
+// returned as *openai.APIError, so that they are retried and classified like OpenAI's,
+// with the message extracted from the error body by errorMessage.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body, out any, errorMessage func([]byte) (string, string)) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, errType := errorMessage(respBody)
+		if message == "" {
+			message = strings.TrimSpace(string(respBody))
+		}
+		return &openai.APIError{HTTPStatusCode: resp.StatusCode, Message: message, Type: errType}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
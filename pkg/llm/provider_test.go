@@ -0,0 +1,169 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+var tagRequest = openai.ChatCompletionRequest{
+	Model:     "test-model",
+	MaxTokens: 100,
+	Messages: []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Tag the content."},
+		{Role: openai.ChatMessageRoleUser, Content: "A Go client for Notion."},
+	},
+	Tools:      []openai.Tool{llm.TagTool([]string{"go", "notion"})},
+	ToolChoice: llm.TagToolChoice(),
+}
+
+// recordServer answers every request with status and response, recording the request body.
+func recordServer(t *testing.T, path string, status int, response string, body *map[string]any, header *http.Header) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, body); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		*header = r.Header
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewProvider(t *testing.T) {
+	RegisterTestingT(t)
+	t.Setenv("OPENAI_API_BASE", "")
+
+	for _, name := range llm.Providers {
+		provider, err := llm.NewProvider(llm.ProviderConfig{Provider: name}, nil)
+		Expect(err).To(BeNil())
+		Expect(provider).NotTo(BeNil())
+	}
+	_, err := llm.NewProvider(llm.ProviderConfig{Provider: "bard"}, nil)
+	Expect(err).To(MatchError(ContainSubstring(`unknown LLM provider "bard"`)))
+
+	Expect(llm.ProviderConfig{}.WithDefaults().Provider).To(Equal(llm.ProviderOpenAI))
+	Expect(llm.ProviderConfig{}.RequiresAPIKey()).To(BeTrue())
+	Expect(llm.ProviderConfig{Provider: llm.ProviderOllama}.RequiresAPIKey()).To(BeFalse())
+	Expect(llm.ProviderConfig{Provider: llm.ProviderAnthropic}.WithDefaults().APIKeyEnv).To(Equal("ANTHROPIC_API_KEY"))
+
+	t.Setenv("OLLAMA_HOST", "gpu-box:11434")
+	Expect(llm.ProviderConfig{Provider: llm.ProviderOllama}.WithDefaults().BaseURL).To(Equal("http://gpu-box:11434"))
+}
+
+func TestOpenAICompatibleProvider(t *testing.T) {
+	RegisterTestingT(t)
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/v1/chat/completions", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"go"},"finish_reason":"stop"}]}`, &body, &header)
+	t.Setenv("OPENAI_API_BASE", server.URL+"/v1")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	provider, err := llm.NewProvider(llm.ProviderConfig{Provider: llm.ProviderOpenAICompatible}, nil)
+	Expect(err).To(BeNil())
+	resp, err := provider.CreateChatCompletion(context.Background(), tagRequest)
+	Expect(err).To(BeNil())
+	Expect(resp.Choices[0].Message.Content).To(Equal("go"))
+	Expect(body["model"]).To(Equal("test-model"))
+}
+
+func TestOllamaClient(t *testing.T) {
+	RegisterTestingT(t)
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/api/chat", http.StatusOK, `{
+		"model": "llama3.1",
+		"created_at": "2024-07-22T20:33:28.123648Z",
+		"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "set_tags", "arguments": {"tags": ["go"]}}}]},
+		"done_reason": "stop",
+		"done": true,
+		"prompt_eval_count": 20,
+		"eval_count": 5
+	}`, &body, &header)
+
+	provider, err := llm.NewProvider(llm.ProviderConfig{Provider: llm.ProviderOllama, BaseURL: server.URL}, nil)
+	Expect(err).To(BeNil())
+	resp, err := provider.CreateChatCompletion(context.Background(), tagRequest)
+	Expect(err).To(BeNil())
+
+	Expect(body["model"]).To(Equal("test-model"))
+	Expect(body["stream"]).To(Equal(false))
+	Expect(body["options"]).To(Equal(map[string]any{"num_predict": float64(100)}))
+	Expect(body["messages"]).To(HaveLen(2))
+	Expect(body["tools"]).To(HaveLen(1))
+
+	suggestion, err := llm.ParseTagSuggestion(resp)
+	Expect(err).To(BeNil())
+	Expect(suggestion.Tags).To(Equal([]string{"go"}))
+	Expect(resp.Usage.TotalTokens).To(Equal(25))
+}
+
+func TestOllamaClient_Error(t *testing.T) {
+	RegisterTestingT(t)
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/api/chat", http.StatusBadRequest, `{"error": "registry.ollama.ai/library/gemma:latest does not support tools"}`, &body, &header)
+
+	provider, err := llm.NewProvider(llm.ProviderConfig{Provider: llm.ProviderOllama, BaseURL: server.URL}, nil)
+	Expect(err).To(BeNil())
+	_, err = provider.CreateChatCompletion(context.Background(), tagRequest)
+	Expect(llm.IsToolsUnsupported(err)).To(BeTrue())
+	Expect(llm.IsRetryable(err)).To(BeFalse())
+}
+
+func TestAnthropicClient(t *testing.T) {
+	RegisterTestingT(t)
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/v1/messages", http.StatusOK, `{
+		"id": "msg_01",
+		"model": "claude-3-5-sonnet-20240620",
+		"content": [{"type": "tool_use", "id": "toolu_01", "name": "set_tags", "input": {"tags": ["go", "notion"], "confidence": 0.9}}],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 30, "output_tokens": 10}
+	}`, &body, &header)
+
+	provider, err := llm.NewProvider(llm.ProviderConfig{Provider: llm.ProviderAnthropic, BaseURL: server.URL}, nil)
+	Expect(err).To(BeNil())
+	resp, err := provider.CreateChatCompletion(context.Background(), tagRequest)
+	Expect(err).To(BeNil())
+
+	Expect(header.Get("x-api-key")).To(Equal("sk-ant-test"))
+	Expect(header.Get("anthropic-version")).NotTo(BeEmpty())
+	Expect(body["system"]).To(Equal("Tag the content."))
+	Expect(body["messages"]).To(Equal([]any{map[string]any{"role": "user", "content": "A Go client for Notion."}}))
+	Expect(body["max_tokens"]).To(Equal(float64(100)))
+	Expect(body["tool_choice"]).To(Equal(map[string]any{"type": "tool", "name": "set_tags"}))
+	Expect(body["tools"]).To(HaveLen(1))
+
+	Expect(resp.Choices[0].FinishReason).To(Equal(openai.FinishReasonToolCalls))
+	suggestion, err := llm.ParseTagSuggestion(resp)
+	Expect(err).To(BeNil())
+	Expect(suggestion).To(Equal(llm.TagSuggestion{Tags: []string{"go", "notion"}, Confidence: 0.9}))
+}
+
+func TestAnthropicClient_Error(t *testing.T) {
+	RegisterTestingT(t)
+	var body map[string]any
+	var header http.Header
+	server := recordServer(t, "/v1/messages", 529, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`, &body, &header)
+
+	provider, err := llm.NewProvider(llm.ProviderConfig{Provider: llm.ProviderAnthropic, BaseURL: server.URL}, nil)
+	Expect(err).To(BeNil())
+	_, err = provider.CreateChatCompletion(context.Background(), tagRequest)
+	Expect(err).To(MatchError("error, status code: 529, message: Overloaded"))
+	Expect(llm.IsRetryable(err)).To(BeTrue())
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Errors for chat completions that didn't produce a usable answer, wrapped in a ResponseError.
+var (
+	// ErrNoChoices is returned when the response has no choices at all.
+	ErrNoChoices = errors.New("response has no choices")
+	// ErrEmptyContent is returned when the model answered with an empty message.
+	ErrEmptyContent = errors.New("response is empty")
+	// ErrTruncated is returned when the answer was cut off at the token limit.
+	ErrTruncated = errors.New("response was truncated at the token limit")
+	// ErrContentFiltered is returned when the provider's content filter blocked the answer.
+	ErrContentFiltered = errors.New("response was blocked by the content filter")
+	// ErrRefused is returned when the model declined to answer.
+	ErrRefused = errors.New("model refused to answer")
+)
+
+// ResponseError describes why a chat completion response can't be used.
+// It unwraps to one of the Err* sentinels above.
+type ResponseError struct {
+	Err          error
+	FinishReason openai.FinishReason
+	// Content is whatever the model answered, e.g. the partial answer of a truncated response.
+	Content string
+}
+
+func (e *ResponseError) Error() string {
+	if e.FinishReason != "" && e.FinishReason != openai.FinishReasonStop {
+		return fmt.Sprintf("%v (finish reason %s)", e.Err, e.FinishReason)
+	}
+	return e.Err.Error()
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// refusalPrefixes are the usual openings of a model declining a request. go-openai
+// doesn't expose the refusal field of the API yet, so refusals are recognized by content.
+var refusalPrefixes = []string{
+	"i'm sorry",
+	"i am sorry",
+	"i'm unable",
+	"i am unable",
+	"i can't",
+	"i cannot",
+	"sorry, i",
+}
+
+// ChoiceContent returns the message of the first choice of resp, or a *ResponseError
+// when the response is missing, empty, truncated, filtered or a refusal.
+func ChoiceContent(resp openai.ChatCompletionResponse) (string, error) {
+	if len(resp.Choices) == 0 {
+		return "", &ResponseError{Err: ErrNoChoices}
+	}
+	choice := resp.Choices[0]
+	content := choice.Message.Content
+	if err := finishError(choice); err != nil {
+		return content, err
+	}
+
+	trimmed := strings.ToLower(strings.TrimSpace(content))
+	if trimmed == "" {
+		return "", &ResponseError{Err: ErrEmptyContent, FinishReason: choice.FinishReason}
+	}
+	for _, prefix := range refusalPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return content, &ResponseError{Err: ErrRefused, FinishReason: choice.FinishReason, Content: content}
+		}
+	}
+	return content, nil
+}
+
+// finishError returns a *ResponseError when the model stopped before completing its answer.
+func finishError(choice openai.ChatCompletionChoice) error {
+	switch choice.FinishReason {
+	case openai.FinishReasonLength:
+		return &ResponseError{Err: ErrTruncated, FinishReason: choice.FinishReason, Content: choice.Message.Content}
+	case openai.FinishReasonContentFilter:
+		return &ResponseError{Err: ErrContentFiltered, FinishReason: choice.FinishReason, Content: choice.Message.Content}
+	}
+	return nil
+}
+
+// NeedsReview reports whether err means a person should look at the input, because
+// the model or its provider wouldn't handle it.
+func NeedsReview(err error) bool {
+	return errors.Is(err, ErrContentFiltered) || errors.Is(err, ErrRefused)
+}
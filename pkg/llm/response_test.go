@@ -0,0 +1,48 @@
+package llm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+func response(content string, reason openai.FinishReason) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+		Message:      openai.ChatCompletionMessage{Content: content},
+		FinishReason: reason,
+	}}}
+}
+
+func TestChoiceContent(t *testing.T) {
+	RegisterTestingT(t)
+
+	content, err := llm.ChoiceContent(response("go\nnotion", openai.FinishReasonStop))
+	Expect(err).To(BeNil())
+	Expect(content).To(Equal("go\nnotion"))
+
+	_, err = llm.ChoiceContent(openai.ChatCompletionResponse{})
+	Expect(errors.Is(err, llm.ErrNoChoices)).To(BeTrue())
+
+	_, err = llm.ChoiceContent(response("  ", openai.FinishReasonStop))
+	Expect(errors.Is(err, llm.ErrEmptyContent)).To(BeTrue())
+
+	content, err = llm.ChoiceContent(response("go\nno", openai.FinishReasonLength))
+	Expect(errors.Is(err, llm.ErrTruncated)).To(BeTrue())
+	Expect(err).To(MatchError("response was truncated at the token limit (finish reason length)"))
+	Expect(content).To(Equal("go\nno"))
+	var respErr *llm.ResponseError
+	Expect(errors.As(err, &respErr)).To(BeTrue())
+	Expect(respErr.Content).To(Equal("go\nno"))
+
+	_, err = llm.ChoiceContent(response("", openai.FinishReasonContentFilter))
+	Expect(errors.Is(err, llm.ErrContentFiltered)).To(BeTrue())
+	Expect(llm.NeedsReview(err)).To(BeTrue())
+
+	_, err = llm.ChoiceContent(response("I'm sorry, but I can't help with that.", openai.FinishReasonStop))
+	Expect(errors.Is(err, llm.ErrRefused)).To(BeTrue())
+	Expect(llm.NeedsReview(err)).To(BeTrue())
+	Expect(llm.NeedsReview(llm.ErrTruncated)).To(BeFalse())
+}
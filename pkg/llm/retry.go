@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/klauern/notion-table-reader/pkg/backoff"
+	"github.com/sashabaranov/go-openai"
+)
+
+// RetryPolicy configures how failed LLM requests are retried. Zero values fall back to defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first one.
+	MaxAttempts int `yaml:"max_attempts"`
+	// BaseDelay is the backoff before the first retry, doubled on every attempt.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the backoff between retries, and how long a rate limit is waited out.
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+// WithDefaults fills in the unset fields of the policy.
+func (p RetryPolicy) WithDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 4
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = time.Second
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = time.Minute
+	}
+	return p
+}
+
+// RateLimits records when the API asked to back off through its rate limit headers,
+// which go-openai doesn't expose on errors. It is shared by all requests of a client.
+type RateLimits struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// Transport returns a http.RoundTripper recording the rate limit headers of 429 responses.
+func (r *RateLimits) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{base: base, limits: r}
+}
+
+// Delay returns how long to wait before the API accepts requests again.
+func (r *RateLimits) Delay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Until(r.until)
+}
+
+func (r *RateLimits) backOff(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if until := time.Now().Add(d); until.After(r.until) {
+		r.until = until
+	}
+}
+
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	limits *RateLimits
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	if delay := RetryAfter(resp.Header); delay > 0 {
+		slog.Warn("LLM API rate limit reached", "retry_after", delay)
+		t.limits.backOff(delay)
+	}
+	return resp, err
+}
+
+// RetryAfter returns the delay requested by the retry-after-ms, retry-after or
+// x-ratelimit-reset-* headers of a response, the longest one if there are several.
+func RetryAfter(header http.Header) time.Duration {
+	var delay time.Duration
+	if ms, err := strconv.Atoi(header.Get("retry-after-ms")); err == nil {
+		delay = max(delay, time.Duration(ms)*time.Millisecond)
+	}
+	if d, ok := backoff.ParseRetryAfter(header.Get("retry-after"), time.Now()); ok {
+		delay = max(delay, d)
+	}
+	for _, name := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(header.Get(name)); err == nil {
+			delay = max(delay, d)
+		}
+	}
+	return delay
+}
+
+// IsRetryable reports whether a failed LLM request may succeed when sent again:
+// rate limits, timeouts, server errors and network failures are retried, while
+// invalid requests, authentication errors and an exhausted quota are not.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Type == "insufficient_quota" || apiErr.Code == "insufficient_quota" {
+			return false
+		}
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusConflict ||
+		status == http.StatusTooManyRequests ||
+		status >= http.StatusInternalServerError
+}
+
+// Retry calls fn until it succeeds, fails with an error that isn't retryable or
+// the policy runs out of attempts. Every attempt first waits for as long as the API
+// asked through limits, so concurrent requests don't run into a known rate limit.
+// Between attempts it backs off exponentially, and it gives up when ctx is done.
+func Retry[T any](ctx context.Context, policy RetryPolicy, limits *RateLimits, op string, fn func(context.Context) (T, error)) (T, error) {
+	policy = policy.WithDefaults()
+	var result T
+	var err error
+	for attempt := 1; ; attempt++ {
+		if delay := min(limits.Delay(), policy.MaxDelay); delay > 0 {
+			slog.Debug("Waiting for LLM rate limit", "op", op, "delay", delay)
+			if err := backoff.Sleep(ctx, delay); err != nil {
+				return result, fmt.Errorf("%s cancelled: %w", op, err)
+			}
+		}
+		result, err = fn(ctx)
+		if err == nil {
+			return result, nil
+		}
+		if !IsRetryable(err) {
+			return result, fmt.Errorf("%s failed: %w", op, err)
+		}
+		if attempt >= policy.MaxAttempts {
+			return result, fmt.Errorf("%s failed after %d attempts: %w", op, attempt, err)
+		}
+
+		delay := backoff.Delay(policy.BaseDelay, policy.MaxDelay, attempt-1)
+		slog.Warn("Retrying LLM request", "op", op, "attempt", attempt, "delay", delay, "err", err)
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return result, fmt.Errorf("%s cancelled: %w", op, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+var fastPolicy = llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestIsRetryable(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(llm.IsRetryable(&openai.APIError{HTTPStatusCode: 429})).To(BeTrue())
+	Expect(llm.IsRetryable(&openai.APIError{HTTPStatusCode: 503})).To(BeTrue())
+	Expect(llm.IsRetryable(&openai.RequestError{HTTPStatusCode: 502, Err: errors.New("bad gateway")})).To(BeTrue())
+	Expect(llm.IsRetryable(&openai.APIError{HTTPStatusCode: 429, Type: "insufficient_quota"})).To(BeFalse())
+	Expect(llm.IsRetryable(&openai.APIError{HTTPStatusCode: 401})).To(BeFalse())
+	Expect(llm.IsRetryable(&openai.APIError{HTTPStatusCode: 400})).To(BeFalse())
+	Expect(llm.IsRetryable(context.Canceled)).To(BeFalse())
+	Expect(llm.IsRetryable(errors.New("error"))).To(BeFalse())
+}
+
+func TestRetry(t *testing.T) {
+	RegisterTestingT(t)
+	calls := 0
+	result, err := llm.Retry(context.Background(), fastPolicy, nil, "test", func(context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", &openai.APIError{HTTPStatusCode: 500}
+		}
+		return "done", nil
+	})
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal("done"))
+	Expect(calls).To(Equal(3))
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	RegisterTestingT(t)
+	calls := 0
+	_, err := llm.Retry(context.Background(), fastPolicy, nil, "test", func(context.Context) (string, error) {
+		calls++
+		return "", &openai.APIError{HTTPStatusCode: 429, Message: "slow down"}
+	})
+	Expect(err).To(MatchError("test failed after 3 attempts: error, status code: 429, message: slow down"))
+	Expect(calls).To(Equal(3))
+
+	calls = 0
+	_, err = llm.Retry(context.Background(), fastPolicy, nil, "test", func(context.Context) (string, error) {
+		calls++
+		return "", &openai.APIError{HTTPStatusCode: 401, Message: "invalid key"}
+	})
+	Expect(err).To(MatchError("test failed: error, status code: 401, message: invalid key"))
+	Expect(calls).To(Equal(1))
+}
+
+func TestRetry_Cancelled(t *testing.T) {
+	RegisterTestingT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	_, err := llm.Retry(ctx, policy, nil, "test", func(context.Context) (string, error) {
+		cancel()
+		return "", &openai.APIError{HTTPStatusCode: 500}
+	})
+	Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+}
+
+func TestRateLimits(t *testing.T) {
+	RegisterTestingT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("retry-after", "1")
+		w.Header().Set("x-ratelimit-reset-requests", "20s")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	limits := &llm.RateLimits{}
+	client := &http.Client{Transport: limits.Transport(nil)}
+	resp, err := client.Get(server.URL)
+	Expect(err).To(BeNil())
+	resp.Body.Close()
+	Expect(limits.Delay()).To(BeNumerically("~", 20*time.Second, time.Second))
+}
+
+func TestRetry_WaitsForRateLimit(t *testing.T) {
+	RegisterTestingT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("retry-after-ms", "100")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	limits := &llm.RateLimits{}
+	client := &http.Client{Transport: limits.Transport(nil)}
+	resp, err := client.Get(server.URL)
+	Expect(err).To(BeNil())
+	resp.Body.Close()
+
+	start := time.Now()
+	var sent time.Duration
+	_, err = llm.Retry(context.Background(), llm.RetryPolicy{MaxDelay: time.Second}, limits, "test", func(context.Context) (string, error) {
+		sent = time.Since(start)
+		return "ok", nil
+	})
+	Expect(err).To(BeNil())
+	Expect(sent).To(BeNumerically(">=", 80*time.Millisecond))
+}
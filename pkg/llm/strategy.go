@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strategies for page content longer than the prompt allows.
+const (
+	// StrategyTruncate keeps the beginning of the content.
+	StrategyTruncate = "truncate"
+	// StrategyHeadTail keeps the beginning and the end of the content.
+	StrategyHeadTail = "head-tail"
+	// StrategySummarize summarizes the content in chunks, and then the summaries, until they fit.
+	StrategySummarize = "summarize"
+)
+
+// ContentStrategies lists the accepted strategy names.
+var ContentStrategies = []string{StrategyTruncate, StrategyHeadTail, StrategySummarize}
+
+// ParseContentStrategy validates a strategy name, defaulting to StrategyTruncate.
+func ParseContentStrategy(name string) (string, error) {
+	switch name {
+	case "":
+		return StrategyTruncate, nil
+	case StrategyTruncate, StrategyHeadTail, StrategySummarize:
+		return name, nil
+	default:
+		return "", fmt.Errorf("unknown content strategy %q, use one of %s", name, strings.Join(ContentStrategies, ", "))
+	}
+}
+
+const (
+	// SummaryTokens is the most tokens a chunk summary may use.
+	SummaryTokens = 256
+	// MaxChunkTokens keeps chunks small enough to be summarized in detail.
+	MaxChunkTokens = 8000
+	// maxSummaryRounds bounds how often summaries are summarized again before truncating them.
+	maxSummaryRounds = 3
+	// omission marks the content dropped between the head and the tail.
+	omission = "\n\n[…]\n\n"
+)
+
+// SummaryPrompt is the system prompt for summarizing a chunk of content.
+const SummaryPrompt = `
+		You summarize a part of a longer document so that it can be categorized later.
+		Write at most 150 words. Keep the topics, technologies, products and names it mentions.
+		Respond with the summary only.
+	`
+
+// HeadTail shortens text to limit tokens by keeping its beginning and its end, which
+// usually hold the introduction and the conclusion.
+func HeadTail(text string, limit int, tok Tokenizer) string {
+	if tok.Count(text) <= limit {
+		return text
+	}
+	half := (limit - tok.Count(omission)) / 2
+	if half <= 0 {
+		return trimText(text, limit, tok)
+	}
+	head := trimText(text, half, tok)
+	tail := tailText(text[len(head):], half, tok)
+	return head + omission + tail
+}
+
+// tailText returns the longest suffix of text with at most limit tokens, starting at a line or word.
+func tailText(text string, limit int, tok Tokenizer) string {
+	// find the shortest cut whose suffix fits, by bisecting over byte offsets
+	lo, hi := 0, len(text)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if tok.Count(text[mid:]) <= limit {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	tail := strings.ToValidUTF8(text[lo:], "")
+	if i := strings.IndexAny(tail, "\n "); i >= 0 && i < len(tail)/4 {
+		tail = tail[i+1:]
+	}
+	return strings.TrimSpace(tail)
+}
+
+// SplitChunks splits text into chunks of at most size tokens, between paragraphs
+// where possible.
+func SplitChunks(text string, size int, tok Tokenizer) []string {
+	var chunks []string
+	var current strings.Builder
+	tokens := 0 // of current, counting a token for each line break
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+		tokens = 0
+	}
+	for _, paragraph := range strings.Split(text, "\n") {
+		count := tok.Count(paragraph)
+		if tokens+count+1 > size {
+			flush()
+		}
+		for count > size {
+			head := trimText(paragraph, size, tok)
+			if head == "" {
+				// a single rune can take more than size tokens
+				_, n := utf8.DecodeRuneInString(paragraph)
+				head = paragraph[:n]
+			}
+			chunks = append(chunks, strings.TrimSpace(head))
+			paragraph = strings.TrimSpace(paragraph[len(head):])
+			count = tok.Count(paragraph)
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(paragraph)
+		tokens += count + 1
+	}
+	flush()
+	return chunks
+}
+
+// Summarize condenses text to limit tokens with map-reduce: the text is split in chunks
+// of chunkSize tokens, each chunk is summarized, and the joined summaries are summarized
+// again while they don't fit. Summaries still too long after a few rounds are truncated.
+// Without room for any content, nothing is summarized and the result is empty.
+func Summarize(text string, limit, chunkSize int, tok Tokenizer, summarize func(chunk string) (string, error)) (string, error) {
+	if limit <= 0 {
+		return "", nil
+	}
+	for round := 0; tok.Count(text) > limit; round++ {
+		if round == maxSummaryRounds {
+			return trimText(text, limit, tok), nil
+		}
+		chunks := SplitChunks(text, chunkSize, tok)
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := summarize(chunk)
+			if err != nil {
+				return "", fmt.Errorf("failed to summarize chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			summaries = append(summaries, strings.TrimSpace(summary))
+		}
+		text = strings.Join(summaries, "\n\n")
+	}
+	return text, nil
+}
@@ -0,0 +1,94 @@
+package llm_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+)
+
+func paragraphs(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Paragraph %d talks about one more topic of the document.", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestParseContentStrategy(t *testing.T) {
+	RegisterTestingT(t)
+	strategy, err := llm.ParseContentStrategy("")
+	Expect(err).To(BeNil())
+	Expect(strategy).To(Equal(llm.StrategyTruncate))
+	for _, name := range llm.ContentStrategies {
+		strategy, err = llm.ParseContentStrategy(name)
+		Expect(err).To(BeNil())
+		Expect(strategy).To(Equal(name))
+	}
+	_, err = llm.ParseContentStrategy("random")
+	Expect(err).To(MatchError(ContainSubstring(`unknown content strategy "random"`)))
+}
+
+func TestHeadTail(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.TokenizerForModel("gpt-4o")
+	text := paragraphs(100)
+
+	result := llm.HeadTail(text, 100, tok)
+	Expect(tok.Count(result)).To(BeNumerically("<=", 100))
+	Expect(result).To(HavePrefix("Paragraph 1 talks"))
+	Expect(result).To(HaveSuffix("Paragraph 100 talks about one more topic of the document."))
+	Expect(result).To(ContainSubstring("[…]"))
+
+	Expect(llm.HeadTail("short", 100, tok)).To(Equal("short"))
+}
+
+func TestSplitChunks(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.TokenizerForModel("gpt-4o")
+	text := paragraphs(100) + "\n" + strings.Repeat("word ", 300)
+
+	chunks := llm.SplitChunks(text, 200, tok)
+	Expect(len(chunks)).To(BeNumerically(">", 1))
+	for _, chunk := range chunks {
+		Expect(tok.Count(chunk)).To(BeNumerically("<=", 200))
+	}
+	Expect(chunks[0]).To(HavePrefix("Paragraph 1 talks"))
+	Expect(strings.Join(chunks, " ")).To(ContainSubstring("Paragraph 100 talks"))
+}
+
+func TestSummarize(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.TokenizerForModel("gpt-4o")
+	text := paragraphs(200)
+
+	var calls int
+	summary, err := llm.Summarize(text, 50, 500, tok, func(chunk string) (string, error) {
+		calls++
+		// summaries keep the first sentence of each chunk
+		first, _, _ := strings.Cut(chunk, "\n")
+		return first, nil
+	})
+	Expect(err).To(BeNil())
+	Expect(tok.Count(summary)).To(BeNumerically("<=", 50))
+	Expect(summary).To(HavePrefix("Paragraph 1 talks"))
+	Expect(calls).To(BeNumerically(">", 5))
+
+	// short text isn't summarized
+	summary, err = llm.Summarize("short", 50, 500, tok, nil)
+	Expect(err).To(BeNil())
+	Expect(summary).To(Equal("short"))
+
+	// without room for content, nothing is summarized
+	summary, err = llm.Summarize(text, 0, 500, tok, nil)
+	Expect(err).To(BeNil())
+	Expect(summary).To(BeEmpty())
+
+	_, err = llm.Summarize(text, 50, 500, tok, func(string) (string, error) {
+		return "", errors.New("boom")
+	})
+	Expect(err).To(MatchError(ContainSubstring("failed to summarize chunk 1 of")))
+}
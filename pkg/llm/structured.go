@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// TagToolName is the function the model calls to return the tags of a page.
+const TagToolName = "set_tags"
+
+// TagSuggestion is the structured answer of the model.
+type TagSuggestion struct {
+	Tags []string `json:"tags"`
+	// Confidence is between 0 and 1, when the model reports it.
+	Confidence float64 `json:"confidence,omitempty"`
+	// Rationale briefly explains the choice of tags, when the model reports it.
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// TagTool declares the function the model has to call with the tags of a page,
+// restricting them to the available tags.
+func TagTool(tags []string) openai.Tool {
+	tag := map[string]any{"type": "string"}
+	if len(tags) > 0 {
+		tag["enum"] = tags
+	}
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        TagToolName,
+			Description: "Set the tags that categorize the content.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"tags": map[string]any{
+						"type":     "array",
+						"items":    tag,
+						"minItems": 1,
+						"maxItems": MaxTags,
+					},
+					"confidence": map[string]any{
+						"type":        "number",
+						"description": "How confident you are in the tags, from 0 to 1.",
+					},
+					"rationale": map[string]any{
+						"type":        "string",
+						"description": "One sentence explaining the choice of tags.",
+					},
+				},
+				"required": []string{"tags"},
+			},
+		},
+	}
+}
+
+// TagToolChoice forces the model to call the TagTool.
+func TagToolChoice() openai.ToolChoice {
+	return openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: TagToolName}}
+}
+
+// ParseTagSuggestion decodes the TagTool call of a response. Models answering in
+// plain text instead are parsed with ParseTagResponse. Unusable responses yield a *ResponseError.
+func ParseTagSuggestion(resp openai.ChatCompletionResponse) (TagSuggestion, error) {
+	if len(resp.Choices) > 0 {
+		if err := finishError(resp.Choices[0]); err != nil {
+			return TagSuggestion{}, err
+		}
+		for _, call := range resp.Choices[0].Message.ToolCalls {
+			if call.Function.Name != TagToolName {
+				continue
+			}
+			var suggestion TagSuggestion
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &suggestion); err != nil {
+				return TagSuggestion{}, fmt.Errorf("failed to decode %s arguments: %w", TagToolName, err)
+			}
+			return suggestion, nil
+		}
+	}
+	content, err := ChoiceContent(resp)
+	if err != nil {
+		return TagSuggestion{}, err
+	}
+	return TagSuggestion{Tags: ParseTagResponse(content)}, nil
+}
+
+// IsToolsUnsupported reports whether the request was rejected because the model
+// doesn't support tool calling.
+func IsToolsUnsupported(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		return false
+	}
+	message := strings.ToLower(apiErr.Message)
+	return strings.Contains(message, "tool") || strings.Contains(message, "function")
+}
@@ -0,0 +1,54 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+)
+
+func toolResponse(arguments string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+		Message: openai.ChatCompletionMessage{ToolCalls: []openai.ToolCall{{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: llm.TagToolName, Arguments: arguments},
+		}}},
+		FinishReason: openai.FinishReasonStop,
+	}}}
+}
+
+func TestTagTool(t *testing.T) {
+	RegisterTestingT(t)
+	data, err := json.Marshal(llm.TagTool([]string{"go", "notion"}))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(ContainSubstring(`"enum":["go","notion"]`))
+	Expect(string(data)).To(ContainSubstring(`"maxItems":3`))
+}
+
+func TestParseTagSuggestion(t *testing.T) {
+	RegisterTestingT(t)
+
+	suggestion, err := llm.ParseTagSuggestion(toolResponse(`{"tags":["go","notion"],"confidence":0.8,"rationale":"A Go Notion client."}`))
+	Expect(err).To(BeNil())
+	Expect(suggestion).To(Equal(llm.TagSuggestion{Tags: []string{"go", "notion"}, Confidence: 0.8, Rationale: "A Go Notion client."}))
+
+	_, err = llm.ParseTagSuggestion(toolResponse(`{"tags":`))
+	Expect(err).To(MatchError(ContainSubstring("failed to decode set_tags arguments")))
+
+	// models without tool calling answer in plain text
+	suggestion, err = llm.ParseTagSuggestion(response("- go\n- notion", openai.FinishReasonStop))
+	Expect(err).To(BeNil())
+	Expect(suggestion.Tags).To(Equal([]string{"go", "notion"}))
+
+	_, err = llm.ParseTagSuggestion(openai.ChatCompletionResponse{})
+	Expect(err).To(MatchError(llm.ErrNoChoices))
+}
+
+func TestIsToolsUnsupported(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(llm.IsToolsUnsupported(&openai.APIError{HTTPStatusCode: 400, Message: "llama2 does not support tools"})).To(BeTrue())
+	Expect(llm.IsToolsUnsupported(&openai.APIError{HTTPStatusCode: 400, Message: "invalid model"})).To(BeFalse())
+	Expect(llm.IsToolsUnsupported(&openai.APIError{HTTPStatusCode: 500, Message: "tool error"})).To(BeFalse())
+}
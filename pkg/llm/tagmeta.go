@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagMetadata describes the tags of the vocabulary by name, to tell the model what
+// ambiguous tags mean. Tags without metadata are listed by name only.
+type TagMetadata map[string]TagInfo
+
+// Describe returns the vocabulary with the metadata of each tag, matching names
+// case-insensitively when there is no exact match.
+func (m TagMetadata) Describe(tags []string) []TagInfo {
+	infos := make([]TagInfo, len(tags))
+	for i, tag := range tags {
+		info, _ := m.lookup(tag)
+		info.Name = tag
+		infos[i] = info
+	}
+	return infos
+}
+
+func (m TagMetadata) lookup(tag string) (TagInfo, bool) {
+	if info, ok := m[tag]; ok {
+		return info, true
+	}
+	for name, info := range m {
+		if strings.EqualFold(name, tag) {
+			return info, true
+		}
+	}
+	return TagInfo{}, false
+}
+
+// ResolveSynonyms replaces the suggestions naming a synonym of a tag in tags by that tag.
+func (m TagMetadata) ResolveSynonyms(suggestions, tags []string) []string {
+	synonyms := map[string]string{}
+	for _, info := range m.Describe(tags) {
+		for _, synonym := range info.Synonyms {
+			synonyms[strings.ToLower(strings.TrimSpace(synonym))] = info.Name
+		}
+	}
+	resolved := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		if tag, ok := synonyms[strings.ToLower(strings.TrimSpace(suggestion))]; ok {
+			resolved[i] = tag
+		} else {
+			resolved[i] = suggestion
+		}
+	}
+	return resolved
+}
+
+// Unknown returns the names described in m that aren't in tags, sorted. Their metadata
+// is unused, usually because the option was renamed or removed in Notion.
+func (m TagMetadata) Unknown(tags []string) []string {
+	var unknown []string
+	for name := range m {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(name, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
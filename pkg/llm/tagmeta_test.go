@@ -0,0 +1,27 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+)
+
+func TestTagMetadata_ResolveSynonyms(t *testing.T) {
+	RegisterTestingT(t)
+	meta := llm.TagMetadata{
+		"Kubernetes": {Synonyms: []string{"k8s"}},
+		"ops":        {Synonyms: []string{"DevOps", "SRE"}},
+	}
+	tags := []string{"kubernetes", "ops", "go"}
+
+	Expect(meta.ResolveSynonyms([]string{"K8s", " devops ", "go", "rust"}, tags)).To(Equal([]string{"kubernetes", "ops", "go", "rust"}))
+}
+
+func TestTagMetadata_Unknown(t *testing.T) {
+	RegisterTestingT(t)
+	meta := llm.TagMetadata{"Go": {}, "perl": {}, "cobol": {}}
+
+	Expect(meta.Unknown([]string{"go", "rust"})).To(Equal([]string{"cobol", "perl"}))
+	Expect(llm.TagMetadata(nil).Unknown([]string{"go"})).To(BeEmpty())
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTags is the most tags a page may get, as instructed by the system prompt.
+const MaxTags = 3
+
+// ErrNoValidTags is returned when none of the suggested tags is in the vocabulary.
+var ErrNoValidTags = errors.New("no suggested tag is in the tag vocabulary")
+
+// TagValidation is the outcome of matching suggested tags to the tag vocabulary.
+type TagValidation struct {
+	// Tags are the matched tags, spelled as in the vocabulary.
+	Tags []string
+	// Rejected are the suggestions that matched no tag, or exceeded MaxTags.
+	Rejected []string
+	// Confidence and Rationale are passed on from the TagSuggestion, when the model reported them.
+	Confidence float64
+	Rationale  string
+}
+
+var listMarker = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+`)
+
+// ParseTagResponse splits the model's answer into suggested tags, one per line or
+// separated by commas, which Notion doesn't allow in tag names. List markers, quotes
+// and blank lines are dropped.
+func ParseTagResponse(response string) []string {
+	var tags []string
+	for _, line := range SplitResponse(response) {
+		line = listMarker.ReplaceAllString(line, "")
+		for _, tag := range strings.Split(line, ",") {
+			tag = strings.Trim(strings.TrimSpace(tag), "\"'`*.")
+			if tag != "" {
+				tags = append(tags, strings.TrimSpace(tag))
+			}
+		}
+	}
+	return tags
+}
+
+// ValidateTags matches suggestions to the vocabulary, ignoring case and separators, and
+// tolerating small typos. Duplicates are dropped and at most MaxTags tags are kept.
+func ValidateTags(suggestions []string, vocabulary []string) TagValidation {
+	var result TagValidation
+	seen := map[string]bool{}
+	for _, suggestion := range suggestions {
+		tag, ok := matchTag(suggestion, vocabulary)
+		switch {
+		case !ok || len(result.Tags) >= MaxTags && !seen[tag]:
+			result.Rejected = append(result.Rejected, suggestion)
+		case !seen[tag]:
+			seen[tag] = true
+			result.Tags = append(result.Tags, tag)
+		}
+	}
+	return result
+}
+
+// matchTag finds the vocabulary tag closest to suggestion. Longer tags allow more typos,
+// and a suggestion equally close to several tags matches none.
+func matchTag(suggestion string, vocabulary []string) (string, bool) {
+	key := tagKey(suggestion)
+	best, bestDistance, ambiguous := "", -1, false
+	for _, tag := range vocabulary {
+		if tag == suggestion {
+			return tag, true
+		}
+		distance := levenshtein(key, tagKey(tag))
+		if distance > utf8.RuneCountInString(tag)/4 {
+			continue
+		}
+		switch {
+		case bestDistance < 0 || distance < bestDistance:
+			best, bestDistance, ambiguous = tag, distance, false
+		case distance == bestDistance:
+			ambiguous = true
+		}
+	}
+	return best, bestDistance >= 0 && !ambiguous
+}
+
+// tagKey lower-cases a tag and unifies the separators between words.
+func tagKey(tag string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_' || r == '/'
+	}), " ")
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,40 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseTagResponse(t *testing.T) {
+	RegisterTestingT(t)
+	response := "- Go\n\n* `notion` \n1. Machine Learning.\n2) **APIs**\nrust, cli\n"
+	Expect(llm.ParseTagResponse(response)).To(Equal([]string{"Go", "notion", "Machine Learning", "APIs", "rust", "cli"}))
+}
+
+func TestValidateTags(t *testing.T) {
+	RegisterTestingT(t)
+	vocabulary := []string{"go", "Notion", "machine-learning", "Kubernetes", "API", "APIs"}
+
+	result := llm.ValidateTags([]string{"Go", "notion", "Machine Learning"}, vocabulary)
+	Expect(result.Tags).To(Equal([]string{"go", "Notion", "machine-learning"}))
+	Expect(result.Rejected).To(BeEmpty())
+
+	// typos are tolerated on longer tags, invented and ambiguous tags are rejected
+	result = llm.ValidateTags([]string{"Kubernets", "do", "Blockchain", "APi"}, vocabulary)
+	Expect(result.Tags).To(Equal([]string{"Kubernetes", "API"}))
+	Expect(result.Rejected).To(Equal([]string{"do", "Blockchain"}))
+
+	result = llm.ValidateTags([]string{"APIs", "api s"}, vocabulary)
+	Expect(result.Tags).To(Equal([]string{"APIs"}))
+	Expect(result.Rejected).To(BeEmpty())
+}
+
+func TestValidateTags_Limit(t *testing.T) {
+	RegisterTestingT(t)
+	vocabulary := []string{"go", "rust", "zig", "c"}
+	result := llm.ValidateTags([]string{"go", "rust", "go", "zig", "c"}, vocabulary)
+	Expect(result.Tags).To(Equal([]string{"go", "rust", "zig"}))
+	Expect(result.Rejected).To(Equal([]string{"c"}))
+}
@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// use the encodings embedded in the binary instead of downloading them
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Tokenizer counts the tokens of text as a model sees them.
+type Tokenizer interface {
+	// Count returns the number of tokens of text.
+	Count(text string) int
+	// Truncate returns the longest prefix of text with at most limit tokens, on a rune boundary.
+	Truncate(text string, limit int) string
+}
+
+var tokenizers sync.Map
+
+// TokenizerForModel returns the BPE tokenizer of OpenAI models, and an estimate for other models.
+func TokenizerForModel(model string) Tokenizer {
+	if tok, ok := tokenizers.Load(model); ok {
+		return tok.(Tokenizer)
+	}
+	var tok Tokenizer = HeuristicTokenizer{}
+	if enc, err := tiktoken.EncodingForModel(model); err == nil {
+		tok = bpeTokenizer{enc}
+	}
+	tokenizers.Store(model, tok)
+	return tok
+}
+
+type bpeTokenizer struct {
+	enc *tiktoken.Tiktoken
+}
+
+func (t bpeTokenizer) Count(text string) int {
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+func (t bpeTokenizer) Truncate(text string, limit int) string {
+	tokens := t.enc.EncodeOrdinary(text)
+	if len(tokens) <= limit {
+		return text
+	}
+	// a token can end in the middle of a multi-byte rune, which is dropped
+	return strings.ToValidUTF8(t.enc.Decode(tokens[:max(limit, 0)]), "")
+}
+
+// HeuristicTokenizer estimates tokens for models without a known tokenizer: about four
+// characters of ASCII text per token, and a token for every other rune.
+type HeuristicTokenizer struct{}
+
+func (HeuristicTokenizer) Count(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+func (HeuristicTokenizer) Truncate(text string, limit int) string {
+	cost := 0 // in quarter tokens
+	for i, r := range text {
+		if r < utf8.RuneSelf {
+			cost++
+		} else {
+			cost += 4
+		}
+		if cost > limit*4 {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// Truncation reports how much content was dropped to fit a token budget.
+type Truncation struct {
+	// Tokens is the size of the content before truncation.
+	Tokens int
+	// Dropped is the number of tokens that didn't fit.
+	Dropped int
+}
+
+// MessageOverhead approximates the tokens used by the chat format around each message.
+const MessageOverhead = 8
+
+const (
+	// maxTitleTokens and maxURLTokens keep overlong titles and URLs from eating into the content.
+	maxTitleTokens = 64
+	maxURLTokens   = 64
+)
+
+// FitTagInput renders the built-in tag input message within budget tokens.
+func FitTagInput(input *TagInput, budget int, tok Tokenizer) (string, Truncation) {
+	message, truncation, err := DefaultPrompts().FitTagInput(input, budget, tok)
+	if err != nil {
+		panic(err)
+	}
+	return message, truncation
+}
+
+// ContentBudget returns how many tokens of content fit in a built-in tag input message of budget tokens.
+func ContentBudget(input *TagInput, budget int, tok Tokenizer) int {
+	limit, err := DefaultPrompts().ContentBudget(input, budget, tok)
+	if err != nil {
+		panic(err)
+	}
+	return limit
+}
+
+// FitTagInput renders the tag input message within budget tokens. The title and URL are
+// capped, and the content gets the rest of the budget, trimmed to the end of a sentence.
+func (p *Prompts) FitTagInput(input *TagInput, budget int, tok Tokenizer) (string, Truncation, error) {
+	truncation := Truncation{Tokens: tok.Count(input.Raw)}
+	limit, err := p.ContentBudget(input, budget, tok)
+	if err != nil {
+		return "", truncation, err
+	}
+	fitted := capTagInput(input, tok)
+	fitted.Raw = trimText(input.Raw, limit, tok)
+	truncation.Dropped = truncation.Tokens - tok.Count(fitted.Raw)
+	message, err := p.RenderTagInput(&fitted)
+	return message, truncation, err
+}
+
+// ContentBudget returns how many tokens of content fit in a tag input message of budget tokens.
+func (p *Prompts) ContentBudget(input *TagInput, budget int, tok Tokenizer) (int, error) {
+	fitted := capTagInput(input, tok)
+	message, err := p.RenderTagInput(&fitted)
+	if err != nil {
+		return 0, err
+	}
+	return budget - tok.Count(message), nil
+}
+
+// capTagInput returns input without content, its title and URL capped to their share of the budget.
+func capTagInput(input *TagInput, tok Tokenizer) TagInput {
+	return TagInput{
+		Title:      trimText(input.Title, maxTitleTokens, tok),
+		URL:        tok.Truncate(input.URL, maxURLTokens),
+		Properties: input.Properties,
+	}
+}
+
+// trimText shortens text to limit tokens, backing up to the end of the last sentence
+// or word when one is close enough to the cut.
+func trimText(text string, limit int, tok Tokenizer) string {
+	if limit <= 0 {
+		return ""
+	}
+	cut := tok.Truncate(text, limit)
+	if len(cut) == len(text) {
+		return text
+	}
+	if i := lastSentenceEnd(cut); i > len(cut)*3/4 {
+		return cut[:i]
+	}
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > len(cut)*3/4 {
+		return strings.TrimRightFunc(cut[:i], unicode.IsSpace)
+	}
+	return cut
+}
+
+// lastSentenceEnd returns the index just past the last sentence terminator or line break of text, or -1.
+func lastSentenceEnd(text string) int {
+	for i := len(text) - 1; i > 0; i-- {
+		switch text[i] {
+		case '\n':
+			return i
+		case ' ':
+			if p := text[i-1]; p == '.' || p == '!' || p == '?' {
+				return i
+			}
+		}
+	}
+	return -1
+}
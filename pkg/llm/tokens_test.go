@@ -0,0 +1,64 @@
+package llm_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	. "github.com/onsi/gomega"
+)
+
+func TestTokenizerForModel(t *testing.T) {
+	RegisterTestingT(t)
+
+	tok := llm.TokenizerForModel("gpt-4o")
+	Expect(tok).NotTo(BeAssignableToTypeOf(llm.HeuristicTokenizer{}))
+	Expect(tok.Count("hello world")).To(Equal(2))
+	Expect(tok.Truncate("hello world", 1)).To(Equal("hello"))
+
+	Expect(llm.TokenizerForModel("claude-3-5-sonnet-20240620")).To(Equal(llm.HeuristicTokenizer{}))
+}
+
+func TestTokenizer_Runes(t *testing.T) {
+	RegisterTestingT(t)
+	text := strings.Repeat("日本語のテキスト🙂", 20)
+	for _, tok := range []llm.Tokenizer{llm.TokenizerForModel("gpt-4"), llm.HeuristicTokenizer{}} {
+		for limit := 0; limit < 40; limit++ {
+			cut := tok.Truncate(text, limit)
+			Expect(utf8.ValidString(cut)).To(BeTrue())
+			Expect(strings.HasPrefix(text, cut)).To(BeTrue())
+			Expect(tok.Count(cut)).To(BeNumerically("<=", limit))
+		}
+	}
+}
+
+func TestHeuristicTokenizer(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.HeuristicTokenizer{}
+	Expect(tok.Count("abcdefgh")).To(Equal(2))
+	Expect(tok.Count("abcdefghi")).To(Equal(3))
+	Expect(tok.Count("日本")).To(Equal(2))
+	Expect(tok.Truncate("abcdefghij", 2)).To(Equal("abcdefgh"))
+}
+
+func TestFitTagInput(t *testing.T) {
+	RegisterTestingT(t)
+	tok := llm.TokenizerForModel("gpt-4o")
+	input := &llm.TagInput{
+		Title: "Release notes",
+		URL:   "https://example.com",
+		Raw:   strings.Repeat("The release fixes a bug in the parser. ", 50),
+	}
+
+	message, truncation := llm.FitTagInput(input, 100, tok)
+	Expect(tok.Count(message)).To(BeNumerically("<=", 100))
+	Expect(message).To(ContainSubstring("Title: Release notes"))
+	Expect(strings.TrimSpace(message)).To(HaveSuffix("parser."))
+	Expect(truncation.Tokens).To(Equal(tok.Count(input.Raw)))
+	Expect(truncation.Dropped).To(BeNumerically(">", 0))
+
+	message, truncation = llm.FitTagInput(input, 10000, tok)
+	Expect(message).To(ContainSubstring(input.Raw))
+	Expect(truncation.Dropped).To(Equal(0))
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/klauern/notion-table-reader/pkg/llm (interfaces: LLMClient,OpenAIClient)
+// Source: github.com/klauern/notion-table-reader/pkg/llm (interfaces: LLMClient,OpenAIClient,EmbeddingClient)
 //
 // Generated by this command:
 //
-//	mockgen -destination=../mocks/mock_llm.go -package=mocks . LLMClient,OpenAIClient
+//	mockgen -destination=../mocks/mock_llm.go -package=mocks . LLMClient,OpenAIClient,EmbeddingClient
 //
 
 // Package mocks is a generated GoMock package.
@@ -123,3 +123,41 @@ func (mr *MockOpenAIClientMockRecorder) CreateChatCompletion(arg0, arg1 any) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChatCompletion", reflect.TypeOf((*MockOpenAIClient)(nil).CreateChatCompletion), arg0, arg1)
 }
+
+// MockEmbeddingClient is a mock of EmbeddingClient interface.
+type MockEmbeddingClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmbeddingClientMockRecorder
+}
+
+// MockEmbeddingClientMockRecorder is the mock recorder for MockEmbeddingClient.
+type MockEmbeddingClientMockRecorder struct {
+	mock *MockEmbeddingClient
+}
+
+// NewMockEmbeddingClient creates a new mock instance.
+func NewMockEmbeddingClient(ctrl *gomock.Controller) *MockEmbeddingClient {
+	mock := &MockEmbeddingClient{ctrl: ctrl}
+	mock.recorder = &MockEmbeddingClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmbeddingClient) EXPECT() *MockEmbeddingClientMockRecorder {
+	return m.recorder
+}
+
+// CreateEmbeddings mocks base method.
+func (m *MockEmbeddingClient) CreateEmbeddings(arg0 context.Context, arg1 openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmbeddings", arg0, arg1)
+	ret0, _ := ret[0].(openai.EmbeddingResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmbeddings indicates an expected call of CreateEmbeddings.
+func (mr *MockEmbeddingClientMockRecorder) CreateEmbeddings(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmbeddings", reflect.TypeOf((*MockEmbeddingClient)(nil).CreateEmbeddings), arg0, arg1)
+}
@@ -155,7 +155,7 @@ func (m *MockNotionTableReader) EXPECT() *MockNotionTableReaderMockRecorder {
 }
 
 // FetchPages mocks base method.
-func (m *MockNotionTableReader) FetchPages(arg0 string, arg1 bool) ([]notion0.PageDetail, error) {
+func (m *MockNotionTableReader) FetchPages(arg0 string, arg1 notion.DatabaseQuery) ([]notion0.PageDetail, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FetchPages", arg0, arg1)
 	ret0, _ := ret[0].([]notion0.PageDetail)
@@ -0,0 +1,301 @@
+package notion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dstotijn/go-notion"
+)
+
+// RenderMarkdown renders a block tree as Markdown.
+func RenderMarkdown(nodes []BlockNode) string {
+	var buf strings.Builder
+	var prev notion.Block
+	number := 0
+	for _, node := range nodes {
+		if _, ok := node.Block.(*notion.NumberedListItemBlock); ok {
+			number++
+		} else {
+			number = 0
+		}
+
+		md := renderNode(node, number)
+		if md == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			// items of the same list are kept together, everything else is its own paragraph
+			if isListItem(prev) && isListItem(node.Block) {
+				buf.WriteString("\n")
+			} else {
+				buf.WriteString("\n\n")
+			}
+		}
+		buf.WriteString(md)
+		prev = node.Block
+	}
+	return buf.String()
+}
+
+func renderNode(node BlockNode, number int) string {
+	switch b := node.Block.(type) {
+	case *notion.ParagraphBlock:
+		return joinBlocks(RichTextToMarkdown(b.RichText), RenderMarkdown(node.Children))
+	case *notion.Heading1Block:
+		return joinBlocks("# "+RichTextToMarkdown(b.RichText), RenderMarkdown(node.Children))
+	case *notion.Heading2Block:
+		return joinBlocks("## "+RichTextToMarkdown(b.RichText), RenderMarkdown(node.Children))
+	case *notion.Heading3Block:
+		return joinBlocks("### "+RichTextToMarkdown(b.RichText), RenderMarkdown(node.Children))
+	case *notion.BulletedListItemBlock:
+		return listItem("- ", "  ", RichTextToMarkdown(b.RichText), node.Children)
+	case *notion.NumberedListItemBlock:
+		if number < 1 {
+			number = 1
+		}
+		marker := fmt.Sprintf("%d. ", number)
+		return listItem(marker, strings.Repeat(" ", len(marker)), RichTextToMarkdown(b.RichText), node.Children)
+	case *notion.ToDoBlock:
+		marker := "- [ ] "
+		if b.Checked != nil && *b.Checked {
+			marker = "- [x] "
+		}
+		return listItem(marker, "  ", RichTextToMarkdown(b.RichText), node.Children)
+	case *notion.ToggleBlock:
+		return listItem("- ", "  ", RichTextToMarkdown(b.RichText), node.Children)
+	case *notion.QuoteBlock:
+		return quote(joinBlocks(RichTextToMarkdown(b.RichText), RenderMarkdown(node.Children)))
+	case *notion.CalloutBlock:
+		text := RichTextToMarkdown(b.RichText)
+		if b.Icon != nil && b.Icon.Emoji != nil {
+			text = *b.Icon.Emoji + " " + text
+		}
+		return quote(joinBlocks(text, RenderMarkdown(node.Children)))
+	case *notion.CodeBlock:
+		language := ""
+		if b.Language != nil && *b.Language != "plain text" {
+			language = *b.Language
+		}
+		code := ExtractRichText(b.RichText)
+		fence := codeFence(code)
+		return fence + language + "\n" + code + "\n" + fence
+	case *notion.EquationBlock:
+		return "$$\n" + b.Expression + "\n$$"
+	case *notion.DividerBlock:
+		return "---"
+	case *notion.TableBlock:
+		return renderTable(b, node.Children)
+	case *notion.ImageBlock:
+		url := fileURL(b.File, b.External)
+		if url == "" {
+			return ExtractRichText(b.Caption)
+		}
+		return "![" + ExtractRichText(b.Caption) + "](" + url + ")"
+	case *notion.FileBlock:
+		return link(ExtractRichText(b.Caption), fileURL(b.File, b.External))
+	case *notion.PDFBlock:
+		return link(ExtractRichText(b.Caption), fileURL(b.File, b.External))
+	case *notion.VideoBlock:
+		return link(ExtractRichText(b.Caption), fileURL(b.File, b.External))
+	case *notion.AudioBlock:
+		return link(ExtractRichText(b.Caption), fileURL(b.File, b.External))
+	case *notion.BookmarkBlock:
+		return link(ExtractRichText(b.Caption), b.URL)
+	case *notion.EmbedBlock:
+		return link("", b.URL)
+	case *notion.LinkPreviewBlock:
+		return link("", b.URL)
+	case *notion.ChildPageBlock:
+		return link(b.Title, pageURL(b.ID()))
+	case *notion.ChildDatabaseBlock:
+		return link(b.Title, pageURL(b.ID()))
+	case *notion.LinkToPageBlock:
+		if b.Type == notion.LinkToPageTypeDatabaseID {
+			return link("", pageURL(b.DatabaseID))
+		}
+		return link("", pageURL(b.PageID))
+	case *notion.ColumnListBlock, *notion.ColumnBlock, *notion.SyncedBlock:
+		return RenderMarkdown(node.Children)
+	default:
+		return ""
+	}
+}
+
+// RichTextToMarkdown renders rich text with its annotations, links, mentions and equations as Markdown.
+func RichTextToMarkdown(richText []notion.RichText) string {
+	var buf strings.Builder
+	for _, t := range richText {
+		text := t.PlainText
+		if t.Equation != nil {
+			text = "$" + t.Equation.Expression + "$"
+		}
+		if text == "" {
+			continue
+		}
+
+		// keep surrounding whitespace outside of the markers, "** bold **" isn't bold
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			buf.WriteString(text)
+			continue
+		}
+		leading := text[:strings.Index(text, trimmed)]
+		trailing := text[len(leading)+len(trimmed):]
+
+		if a := t.Annotations; a != nil && t.Equation == nil {
+			if a.Code {
+				trimmed = "`" + trimmed + "`"
+			}
+			if a.Bold {
+				trimmed = "**" + trimmed + "**"
+			}
+			if a.Italic {
+				trimmed = "_" + trimmed + "_"
+			}
+			if a.Strikethrough {
+				trimmed = "~~" + trimmed + "~~"
+			}
+		}
+		if t.HRef != nil && *t.HRef != "" {
+			trimmed = "[" + trimmed + "](" + *t.HRef + ")"
+		}
+
+		buf.WriteString(leading + trimmed + trailing)
+	}
+	return buf.String()
+}
+
+func renderTable(table *notion.TableBlock, rows []BlockNode) string {
+	var cells [][]string
+	width := table.TableWidth
+	for _, row := range rows {
+		r, ok := row.Block.(*notion.TableRowBlock)
+		if !ok {
+			continue
+		}
+		line := make([]string, len(r.Cells))
+		for i, cell := range r.Cells {
+			line[i] = strings.ReplaceAll(RichTextToMarkdown(cell), "|", `\|`)
+		}
+		cells = append(cells, line)
+		width = max(width, len(line))
+	}
+	if len(cells) == 0 {
+		return ""
+	}
+
+	// Markdown tables always have a header row, so add an empty one when Notion has none
+	header := make([]string, width)
+	if table.HasColumnHeader {
+		header, cells = cells[0], cells[1:]
+	}
+	separator := make([]string, width)
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	lines := []string{tableRow(header, width), tableRow(separator, width)}
+	for _, line := range cells {
+		lines = append(lines, tableRow(line, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tableRow(cells []string, width int) string {
+	padded := make([]string, width)
+	copy(padded, cells)
+	return strings.TrimRight("| "+strings.Join(padded, " | ")+" |", " ")
+}
+
+func listItem(marker, indentation, text string, children []BlockNode) string {
+	item := marker + indent(text, indentation, false)
+	body := RenderMarkdown(children)
+	if body == "" {
+		return item
+	}
+	separator := "\n\n"
+	if isListItem(children[0].Block) {
+		separator = "\n"
+	}
+	return item + separator + indent(body, indentation, true)
+}
+
+func isListItem(block notion.Block) bool {
+	switch block.(type) {
+	case *notion.BulletedListItemBlock, *notion.NumberedListItemBlock, *notion.ToDoBlock, *notion.ToggleBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// indent prefixes the lines of text with indentation, skipping the first line unless first is set.
+func indent(text, indentation string, first bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if (i > 0 || first) && line != "" {
+			lines[i] = indentation + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeFence returns a fence of backticks longer than any run of backticks in code,
+// so that the code can't close its block early.
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinBlocks(blocks ...string) string {
+	var nonEmpty []string
+	for _, b := range blocks {
+		if b != "" {
+			nonEmpty = append(nonEmpty, b)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
+
+func link(text, url string) string {
+	if url == "" {
+		return text
+	}
+	if text == "" {
+		text = url
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func fileURL(file *notion.FileFile, external *notion.FileExternal) string {
+	if file != nil {
+		return file.URL
+	}
+	if external != nil {
+		return external.URL
+	}
+	return ""
+}
+
+func pageURL(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "https://www.notion.so/" + strings.ReplaceAll(id, "-", "")
+}
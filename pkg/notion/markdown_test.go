@@ -0,0 +1,87 @@
+package notion_test
+
+import (
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	. "github.com/onsi/gomega"
+)
+
+func text(s string) []notion.RichText {
+	return []notion.RichText{{PlainText: s}}
+}
+
+func TestRenderMarkdown_Lists(t *testing.T) {
+	RegisterTestingT(t)
+	checked := true
+	nodes := []myNotion.BlockNode{
+		{Block: &notion.NumberedListItemBlock{RichText: text("First")}, Children: myNotion.NewBlockNodes(
+			&notion.BulletedListItemBlock{RichText: text("Nested")},
+		)},
+		{Block: &notion.NumberedListItemBlock{RichText: text("Second")}},
+		{Block: &notion.ParagraphBlock{RichText: text("Break")}},
+		{Block: &notion.NumberedListItemBlock{RichText: text("Restart")}},
+		{Block: &notion.ToDoBlock{RichText: text("Done"), Checked: &checked}},
+		{Block: &notion.ToDoBlock{RichText: text("Open")}},
+	}
+
+	expected := "1. First\n   - Nested\n2. Second\n\nBreak\n\n1. Restart\n- [x] Done\n- [ ] Open"
+	Expect(myNotion.RenderMarkdown(nodes)).To(Equal(expected))
+}
+
+func TestRenderMarkdown_Blocks(t *testing.T) {
+	RegisterTestingT(t)
+	language := "go"
+	emoji := "💡"
+	nodes := []myNotion.BlockNode{
+		{Block: &notion.QuoteBlock{RichText: text("Quoted")}},
+		{Block: &notion.CalloutBlock{RichText: text("Note"), Icon: &notion.Icon{Emoji: &emoji}}},
+		{Block: &notion.CodeBlock{RichText: text("fmt.Println()"), Language: &language}},
+		{Block: &notion.DividerBlock{}},
+		{Block: &notion.EquationBlock{Expression: "e = mc^2"}},
+		{Block: &notion.ImageBlock{External: &notion.FileExternal{URL: "https://example.com/a.png"}, Caption: text("Diagram")}},
+		{Block: &notion.BookmarkBlock{URL: "https://example.com"}},
+	}
+
+	expected := "> Quoted\n\n> 💡 Note\n\n```go\nfmt.Println()\n```\n\n---\n\n$$\ne = mc^2\n$$\n\n" +
+		"![Diagram](https://example.com/a.png)\n\n[https://example.com](https://example.com)"
+	Expect(myNotion.RenderMarkdown(nodes)).To(Equal(expected))
+}
+
+func TestRenderMarkdown_CodeFence(t *testing.T) {
+	RegisterTestingT(t)
+	nodes := []myNotion.BlockNode{
+		{Block: &notion.CodeBlock{RichText: text("```go\nfmt.Println()\n```")}},
+		{Block: &notion.ParagraphBlock{RichText: text("After")}},
+	}
+	Expect(myNotion.RenderMarkdown(nodes)).To(Equal("````\n```go\nfmt.Println()\n```\n````\n\nAfter"))
+}
+
+func TestRenderMarkdown_Table(t *testing.T) {
+	RegisterTestingT(t)
+	nodes := []myNotion.BlockNode{
+		{Block: &notion.TableBlock{TableWidth: 2, HasColumnHeader: true}, Children: myNotion.NewBlockNodes(
+			&notion.TableRowBlock{Cells: [][]notion.RichText{text("Name"), text("Value")}},
+			&notion.TableRowBlock{Cells: [][]notion.RichText{text("a|b"), text("1")}},
+		)},
+	}
+
+	expected := "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |"
+	Expect(myNotion.RenderMarkdown(nodes)).To(Equal(expected))
+}
+
+func TestRichTextToMarkdown(t *testing.T) {
+	RegisterTestingT(t)
+	href := "https://example.com"
+	richText := []notion.RichText{
+		{PlainText: "bold ", Annotations: &notion.Annotations{Bold: true}},
+		{PlainText: "code", Annotations: &notion.Annotations{Code: true}},
+		{PlainText: " and "},
+		{PlainText: "link", HRef: &href},
+		{PlainText: " ", Annotations: &notion.Annotations{Italic: true}},
+		{Type: notion.RichTextTypeEquation, Equation: &notion.Equation{Expression: "x^2"}},
+	}
+
+	Expect(myNotion.RichTextToMarkdown(richText)).To(Equal("**bold** `code` and [link](https://example.com) $x^2$"))
+}
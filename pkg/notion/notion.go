@@ -3,7 +3,9 @@ package notion
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dstotijn/go-notion"
 	"github.com/klauern/notion-table-reader/pkg/llm"
@@ -12,7 +14,7 @@ import (
 //go:generate mockgen -destination=../mocks/mock_notion.go -package=mocks . NotionClient,NotionTableReader
 
 type NotionTableReader interface {
-	FetchPages(databaseID string, untagged bool) ([]PageDetail, error)
+	FetchPages(databaseID string, query notion.DatabaseQuery) ([]PageDetail, error)
 	TagPage(id string, availableTags []string) error
 	NotionClient
 }
@@ -26,44 +28,69 @@ type NotionClient interface {
 }
 
 type PageDetail struct {
-	ID   string
-	Name string
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DatabaseDetail describes a database and where it lives.
+type DatabaseDetail struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	ParentType string `json:"parent_type"`
+	ParentID   string `json:"parent_id"`
+}
+
+// TagDetail is a tag available in the tag column.
+type TagDetail struct {
+	Name string `json:"name"`
+}
+
+// NewDatabaseDetail describes db.
+func NewDatabaseDetail(db notion.Database) DatabaseDetail {
+	detail := DatabaseDetail{
+		ID:         db.ID,
+		Title:      ExtractRichText(db.Title),
+		ParentType: strings.TrimSuffix(string(db.Parent.Type), "_id"),
+	}
+	switch db.Parent.Type {
+	case notion.ParentTypeDatabase:
+		detail.ParentID = db.Parent.DatabaseID
+	case notion.ParentTypePage:
+		detail.ParentID = db.Parent.PageID
+	case notion.ParentTypeBlock:
+		detail.ParentID = db.Parent.BlockID
+	}
+	return detail
 }
 
 type PageWithBlocks struct {
 	Page   *notion.Page
-	Blocks []notion.Block
+	Blocks []BlockNode
 }
 
-func (p PageWithBlocks) NormalizeBody() string {
-	var buf bytes.Buffer
-	for _, block := range p.Blocks {
-		buf.WriteString(BlockToMarkdown(block))
+// BlockNode is a block together with its nested children.
+type BlockNode struct {
+	Block    notion.Block
+	Children []BlockNode
+}
+
+// NewBlockNodes wraps blocks without children into BlockNodes.
+func NewBlockNodes(blocks ...notion.Block) []BlockNode {
+	nodes := make([]BlockNode, len(blocks))
+	for i, block := range blocks {
+		nodes[i] = BlockNode{Block: block}
 	}
-	return buf.String()
+	return nodes
+}
+
+// NormalizeBody renders the page content as Markdown.
+func (p PageWithBlocks) NormalizeBody() string {
+	return RenderMarkdown(p.Blocks)
 }
 
+// BlockToMarkdown renders a single block, without its children, as Markdown.
 func BlockToMarkdown(block notion.Block) string {
-	switch b := block.(type) {
-	case *notion.ParagraphBlock:
-		return ExtractRichText(b.RichText)
-	case *notion.Heading1Block:
-		return ExtractRichText(b.RichText)
-	case *notion.Heading2Block:
-		return ExtractRichText(b.RichText)
-	case *notion.Heading3Block:
-		return ExtractRichText(b.RichText)
-	case *notion.BulletedListItemBlock:
-		return ExtractRichText(b.RichText)
-	case *notion.NumberedListItemBlock:
-		return ExtractRichText(b.RichText)
-	case *notion.ToDoBlock:
-		return ExtractRichText(b.RichText)
-	case *notion.CalloutBlock:
-		return ExtractRichText(b.RichText)
-	default:
-		return ""
-	}
+	return renderNode(BlockNode{Block: block}, 1)
 }
 
 func ExtractRichText(richText []notion.RichText) string {
@@ -74,18 +101,119 @@ func ExtractRichText(richText []notion.RichText) string {
 	return buf.String()
 }
 
-func NewTagInput(page *PageWithBlocks) *llm.TagInput {
+// NewTagInput describes a page to the tagger. The tagColumn property is left out of
+// the properties, so the model doesn't see the tags the page already has.
+func NewTagInput(page *PageWithBlocks, tagColumn string) *llm.TagInput {
 	tag := &llm.TagInput{
-		Title: page.Page.Properties.(notion.DatabasePageProperties)["Name"].Title[0].PlainText,
+		Title: PageTitle(page.Page),
 		URL:   page.Page.URL,
 		Raw:   page.NormalizeBody(),
 	}
+	if props, ok := page.Page.Properties.(notion.DatabasePageProperties); ok {
+		tag.Properties = make(map[string]string, len(props))
+		for name, prop := range props {
+			if name == tagColumn {
+				continue
+			}
+			if text := PropertyText(prop); text != "" {
+				tag.Properties[name] = text
+			}
+		}
+	}
 	return tag
 }
 
-// PrintPageDetails prints the details of the provided pages.
-func PrintPageDetails(pages []PageDetail) {
-	for _, page := range pages {
-		fmt.Printf("Page(%s): %s\n", page.ID, page.Name)
+// PropertyText returns the value of a page property as plain text, or "" for empty
+// properties and types without a textual value, like files and relations.
+func PropertyText(prop notion.DatabasePageProperty) string {
+	switch prop.Type {
+	case notion.DBPropTypeTitle:
+		return ExtractRichText(prop.Title)
+	case notion.DBPropTypeRichText:
+		return ExtractRichText(prop.RichText)
+	case notion.DBPropTypeNumber:
+		if prop.Number != nil {
+			return strconv.FormatFloat(*prop.Number, 'f', -1, 64)
+		}
+	case notion.DBPropTypeSelect:
+		if prop.Select != nil {
+			return prop.Select.Name
+		}
+	case notion.DBPropTypeStatus:
+		if prop.Status != nil {
+			return prop.Status.Name
+		}
+	case notion.DBPropTypeMultiSelect:
+		names := make([]string, len(prop.MultiSelect))
+		for i, opt := range prop.MultiSelect {
+			names[i] = opt.Name
+		}
+		return strings.Join(names, ", ")
+	case notion.DBPropTypeDate:
+		if prop.Date != nil {
+			if prop.Date.End != nil {
+				return formatDate(prop.Date.Start) + " - " + formatDate(*prop.Date.End)
+			}
+			return formatDate(prop.Date.Start)
+		}
+	case notion.DBPropTypeCheckbox:
+		if prop.Checkbox != nil {
+			return strconv.FormatBool(*prop.Checkbox)
+		}
+	case notion.DBPropTypeURL:
+		if prop.URL != nil {
+			return *prop.URL
+		}
+	case notion.DBPropTypeEmail:
+		if prop.Email != nil {
+			return *prop.Email
+		}
+	case notion.DBPropTypePhoneNumber:
+		if prop.PhoneNumber != nil {
+			return *prop.PhoneNumber
+		}
+	case notion.DBPropTypeCreatedTime:
+		if prop.CreatedTime != nil {
+			return prop.CreatedTime.Format(time.RFC3339)
+		}
+	case notion.DBPropTypeLastEditedTime:
+		if prop.LastEditedTime != nil {
+			return prop.LastEditedTime.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+func formatDate(dt notion.DateTime) string {
+	if dt.HasTime() {
+		return dt.Format(time.RFC3339)
+	}
+	return dt.Format(time.DateOnly)
+}
+
+// PageTitle returns the plain text of the page's title property.
+func PageTitle(page *notion.Page) string {
+	props, ok := page.Properties.(notion.DatabasePageProperties)
+	if !ok {
+		return ""
+	}
+	for _, prop := range props {
+		if prop.Type == notion.DBPropTypeTitle {
+			return ExtractRichText(prop.Title)
+		}
+	}
+	return ExtractRichText(props["Name"].Title)
+}
+
+// PageMultiSelect returns the option names selected in the page's multi-select property.
+func PageMultiSelect(page *notion.Page, property string) []string {
+	props, ok := page.Properties.(notion.DatabasePageProperties)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, opt := range props[property].MultiSelect {
+		names = append(names, opt.Name)
 	}
+	return names
 }
@@ -0,0 +1,42 @@
+package notion_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	. "github.com/onsi/gomega"
+)
+
+func TestNewTagInput_Properties(t *testing.T) {
+	RegisterTestingT(t)
+	number := 4.5
+	checked := false
+	page := &myNotion.PageWithBlocks{Page: &notion.Page{
+		URL: "https://notion.so/page",
+		Properties: notion.DatabasePageProperties{
+			"Name":     {Type: notion.DBPropTypeTitle, Title: text("Go generics")},
+			"Status":   {Type: notion.DBPropTypeStatus, Status: &notion.SelectOptions{Name: "Inbox"}},
+			"Source":   {Type: notion.DBPropTypeMultiSelect, MultiSelect: []notion.SelectOptions{{Name: "blog"}, {Name: "rss"}}},
+			"Rating":   {Type: notion.DBPropTypeNumber, Number: &number},
+			"Read":     {Type: notion.DBPropTypeCheckbox, Checkbox: &checked},
+			"Added":    {Type: notion.DBPropTypeDate, Date: &notion.Date{Start: notion.NewDateTime(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), false)}},
+			"Notes":    {Type: notion.DBPropTypeRichText},
+			"Files":    {Type: notion.DBPropTypeFiles},
+			"Relation": {Type: notion.DBPropTypeRelation},
+			"Tags":     {Type: notion.DBPropTypeMultiSelect, MultiSelect: []notion.SelectOptions{{Name: "go"}}},
+		},
+	}}
+
+	input := myNotion.NewTagInput(page, "Tags")
+	Expect(input.Title).To(Equal("Go generics"))
+	Expect(input.Properties).To(Equal(map[string]string{
+		"Name":   "Go generics",
+		"Status": "Inbox",
+		"Source": "blog, rss",
+		"Rating": "4.5",
+		"Read":   "false",
+		"Added":  "2026-01-02",
+	}))
+}
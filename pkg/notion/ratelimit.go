@@ -0,0 +1,233 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg/backoff"
+)
+
+// RetryOptions configures the throttling and retries of a RateLimitedClient.
+// Unset values fall back to defaults matching the Notion API limits.
+type RetryOptions struct {
+	// RequestsPerSecond is the sustained request rate, Notion allows about 3.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the number of requests that may be sent at once.
+	Burst int `yaml:"burst"`
+	// MaxRetries is the number of retries of a failed request, 0 disables retries.
+	// Unset, failed requests are retried 5 times.
+	MaxRetries *int `yaml:"max_retries"`
+	// BaseDelay is the backoff before the first retry, doubled on every attempt.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the backoff between retries.
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.RequestsPerSecond <= 0 {
+		o.RequestsPerSecond = 3
+	}
+	if o.Burst <= 0 {
+		o.Burst = 3
+	}
+	if o.MaxRetries == nil || *o.MaxRetries < 0 {
+		retries := 5
+		o.MaxRetries = &retries
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 500 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 30 * time.Second
+	}
+	return o
+}
+
+// Limiter is a token bucket shared by all requests to the API. It can be paused
+// when the API asks to back off with Retry-After.
+type Limiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+// NewLimiter returns a Limiter allowing rate requests per second, with bursts of burst requests.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// PauseUntil holds back all requests until t.
+func (l *Limiter) PauseUntil(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.pausedUntil) {
+		l.pausedUntil = t
+	}
+}
+
+// RetryAfterTransport pauses the Limiter for the duration of the Retry-After header
+// of rate limited responses, which the Notion client doesn't expose in its errors.
+type RetryAfterTransport struct {
+	Base    http.RoundTripper
+	Limiter *Limiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	if delay, ok := backoff.ParseRetryAfter(resp.Header.Get("Retry-After"), t.Limiter.now()); ok {
+		slog.Warn("Notion API rate limit reached", "retry_after", delay)
+		t.Limiter.PauseUntil(t.Limiter.now().Add(delay))
+	}
+	return resp, err
+}
+
+// RateLimitedClient decorates a NotionClient with client-side rate limiting and
+// retries of rate limited, conflicting and failed requests, using exponential
+// backoff with jitter.
+type RateLimitedClient struct {
+	client  NotionClient
+	limiter *Limiter
+	opts    RetryOptions
+}
+
+var _ NotionClient = (*RateLimitedClient)(nil)
+
+// NewRateLimitedClient wraps client, throttling requests through limiter.
+// A nil limiter creates one from opts.
+func NewRateLimitedClient(client NotionClient, limiter *Limiter, opts RetryOptions) *RateLimitedClient {
+	opts = opts.withDefaults()
+	if limiter == nil {
+		limiter = NewLimiter(opts.RequestsPerSecond, opts.Burst)
+	}
+	return &RateLimitedClient{client: client, limiter: limiter, opts: opts}
+}
+
+// NewNotionClient returns a Notion API client that is rate limited and retries failed requests.
+func NewNotionClient(apiKey string, opts RetryOptions) *RateLimitedClient {
+	opts = opts.withDefaults()
+	limiter := NewLimiter(opts.RequestsPerSecond, opts.Burst)
+	httpClient := &http.Client{Transport: &RetryAfterTransport{Limiter: limiter}}
+	return NewRateLimitedClient(notion.NewClient(apiKey, notion.WithHTTPClient(httpClient)), limiter, opts)
+}
+
+func retry[T any](c *RateLimitedClient, ctx context.Context, op string, fn func() (T, error)) (T, error) {
+	var result T
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = c.limiter.Wait(ctx); err != nil {
+			return result, err
+		}
+		result, err = fn()
+		if err == nil || attempt >= *c.opts.MaxRetries || !IsRetryable(err) {
+			return result, err
+		}
+
+		delay := backoff.Delay(c.opts.BaseDelay, c.opts.MaxDelay, attempt)
+		slog.Debug("Retrying Notion request", "op", op, "attempt", attempt+1, "delay", delay, "err", err)
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return result, err
+		}
+	}
+}
+
+// IsRetryable reports whether a failed Notion request may succeed when sent again.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *notion.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status == http.StatusTooManyRequests ||
+			apiErr.Status == http.StatusConflict ||
+			apiErr.Status >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *RateLimitedClient) FindDatabaseByID(ctx context.Context, databaseId string) (notion.Database, error) {
+	return retry(c, ctx, "FindDatabaseByID", func() (notion.Database, error) {
+		return c.client.FindDatabaseByID(ctx, databaseId)
+	})
+}
+
+func (c *RateLimitedClient) Search(ctx context.Context, opts *notion.SearchOpts) (notion.SearchResponse, error) {
+	return retry(c, ctx, "Search", func() (notion.SearchResponse, error) {
+		return c.client.Search(ctx, opts)
+	})
+}
+
+func (c *RateLimitedClient) QueryDatabase(ctx context.Context, databaseId string, query *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+	return retry(c, ctx, "QueryDatabase", func() (notion.DatabaseQueryResponse, error) {
+		return c.client.QueryDatabase(ctx, databaseId, query)
+	})
+}
+
+func (c *RateLimitedClient) FindPageByID(ctx context.Context, pageId string) (notion.Page, error) {
+	return retry(c, ctx, "FindPageByID", func() (notion.Page, error) {
+		return c.client.FindPageByID(ctx, pageId)
+	})
+}
+
+func (c *RateLimitedClient) FindBlockChildrenByID(ctx context.Context, blockId string, pagination *notion.PaginationQuery) (notion.BlockChildrenResponse, error) {
+	return retry(c, ctx, "FindBlockChildrenByID", func() (notion.BlockChildrenResponse, error) {
+		return c.client.FindBlockChildrenByID(ctx, blockId, pagination)
+	})
+}
+
+func (c *RateLimitedClient) UpdatePage(ctx context.Context, pageId string, params notion.UpdatePageParams) (notion.Page, error) {
+	return retry(c, ctx, "UpdatePage", func() (notion.Page, error) {
+		return c.client.UpdatePage(ctx, pageId, params)
+	})
+}
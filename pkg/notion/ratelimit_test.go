@@ -0,0 +1,109 @@
+package notion_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var fastRetries = myNotion.RetryOptions{RequestsPerSecond: 1000, Burst: 10, MaxRetries: retries(2), BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+func retries(n int) *int {
+	return &n
+}
+
+func apiError(status int, code string) error {
+	return fmt.Errorf("notion: failed to find page: %w", &notion.APIError{Status: status, Code: code})
+}
+
+func TestRateLimitedClient_Retries(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := myNotion.NewRateLimitedClient(mockNotionClient, nil, fastRetries)
+
+	gomock.InOrder(
+		mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "page").Return(notion.Page{}, apiError(429, "rate_limited")),
+		mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "page").Return(notion.Page{}, apiError(502, "")),
+		mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "page").Return(notion.Page{ID: "page"}, nil),
+	)
+
+	page, err := client.FindPageByID(context.Background(), "page")
+	Expect(err).To(BeNil())
+	Expect(page.ID).To(Equal("page"))
+}
+
+func TestRateLimitedClient_GivesUp(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := myNotion.NewRateLimitedClient(mockNotionClient, nil, fastRetries)
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "conflict").Return(notion.Page{}, apiError(409, "conflict_error")).Times(3)
+	_, err := client.FindPageByID(context.Background(), "conflict")
+	Expect(errors.Is(err, notion.ErrConflict)).To(BeTrue())
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "unauthorized").Return(notion.Page{}, apiError(401, "unauthorized")).Times(1)
+	_, err = client.FindPageByID(context.Background(), "unauthorized")
+	Expect(errors.Is(err, notion.ErrUnauthorized)).To(BeTrue())
+}
+
+func TestRateLimitedClient_NoRetries(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	opts := fastRetries
+	opts.MaxRetries = retries(0)
+	client := myNotion.NewRateLimitedClient(mockNotionClient, nil, opts)
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "page").Return(notion.Page{}, apiError(429, "rate_limited")).Times(1)
+	_, err := client.FindPageByID(context.Background(), "page")
+	Expect(errors.Is(err, notion.ErrRateLimited)).To(BeTrue())
+}
+
+func TestLimiter(t *testing.T) {
+	RegisterTestingT(t)
+	limiter := myNotion.NewLimiter(100, 2)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		Expect(limiter.Wait(context.Background())).To(Succeed())
+	}
+	// two requests are in the burst, the other two wait 10ms each
+	Expect(time.Since(start)).To(BeNumerically(">=", 15*time.Millisecond))
+
+	limiter.PauseUntil(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	Expect(limiter.Wait(ctx)).To(MatchError(context.DeadlineExceeded))
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	RegisterTestingT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	limiter := myNotion.NewLimiter(100, 10)
+	client := &http.Client{Transport: &myNotion.RetryAfterTransport{Limiter: limiter}}
+	resp, err := client.Get(server.URL)
+	Expect(err).To(BeNil())
+	resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	Expect(limiter.Wait(ctx)).To(MatchError(context.DeadlineExceeded))
+}
@@ -0,0 +1,197 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Output formats supported by Renderer.
+const (
+	FormatTable     = "table"
+	FormatJSON      = "json"
+	FormatJSONLines = "jsonl"
+	FormatCSV       = "csv"
+	FormatTemplate  = "template"
+)
+
+// OutputFormats lists the accepted --output values.
+var OutputFormats = []string{FormatTable, FormatJSON, FormatJSONLines, FormatCSV, FormatTemplate + "=<go template>"}
+
+// Renderer writes lists of records in one of the output formats. Records are structs,
+// and their json tags name the fields in every format, including templates.
+type Renderer struct {
+	Format   string
+	Template *template.Template
+	Out      io.Writer
+}
+
+// NewRenderer parses an output format, where template=<go template> renders each record
+// with the template, e.g. template={{.id}}.
+func NewRenderer(format string, out io.Writer) (*Renderer, error) {
+	r := &Renderer{Format: strings.ToLower(format), Out: out}
+	if name, text, ok := strings.Cut(format, "="); ok && (name == FormatTemplate || name == "go-template") {
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %w", err)
+		}
+		r.Format, r.Template = FormatTemplate, tmpl
+		return r, nil
+	}
+	switch r.Format {
+	case "":
+		r.Format = FormatTable
+	case FormatTable, FormatJSON, FormatJSONLines, FormatCSV:
+	case "json-lines", "ndjson":
+		r.Format = FormatJSONLines
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+	return r, nil
+}
+
+// Render writes records, which must be a slice of structs.
+func (r *Renderer) Render(records any) error {
+	rows := reflect.ValueOf(records)
+	if rows.Kind() != reflect.Slice {
+		return fmt.Errorf("can't render %T, expected a slice", records)
+	}
+
+	switch r.Format {
+	case FormatJSON:
+		if rows.IsNil() {
+			records = []struct{}{}
+		}
+		enc := json.NewEncoder(r.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case FormatJSONLines:
+		enc := json.NewEncoder(r.Out)
+		for i := 0; i < rows.Len(); i++ {
+			if err := enc.Encode(rows.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	columns := recordColumns(rows.Type().Elem())
+	switch r.Format {
+	case FormatCSV:
+		w := csv.NewWriter(r.Out)
+		if err := w.Write(columns); err != nil {
+			return err
+		}
+		for i := 0; i < rows.Len(); i++ {
+			if err := w.Write(recordValues(rows.Index(i))); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case FormatTemplate:
+		for i := 0; i < rows.Len(); i++ {
+			fields := make(map[string]any, len(columns))
+			for j, value := range recordFields(rows.Index(i)) {
+				fields[columns[j]] = value
+			}
+			if err := r.Template.Execute(r.Out, fields); err != nil {
+				return fmt.Errorf("failed to render output template: %w", err)
+			}
+			if _, err := fmt.Fprintln(r.Out); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		w := tabwriter.NewWriter(r.Out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+		for i := 0; i < rows.Len(); i++ {
+			fmt.Fprintln(w, strings.Join(recordValues(rows.Index(i)), "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// recordColumns returns the json names of the exported fields of a record type.
+func recordColumns(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var columns []string
+	for _, field := range reflect.VisibleFields(t) {
+		if name, ok := jsonName(field); ok {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+func recordFields(v reflect.Value) []any {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	var values []any
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if _, ok := jsonName(field); ok {
+			values = append(values, v.FieldByIndex(field.Index).Interface())
+		}
+	}
+	return values
+}
+
+func recordValues(v reflect.Value) []string {
+	fields := recordFields(v)
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = formatValue(field)
+	}
+	return values
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() || field.Anonymous {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return name, true
+	}
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		return formatValue(rv.Elem().Interface())
+	}
+	return fmt.Sprint(value)
+}
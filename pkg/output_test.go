@@ -0,0 +1,56 @@
+package pkg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg"
+	myNotion "github.com/klauern/notion-table-reader/pkg/notion"
+	. "github.com/onsi/gomega"
+)
+
+var outputPages = []myNotion.PageDetail{
+	{ID: "page-1", Name: "Hello"},
+	{ID: "page-2", Name: "Hello, World"},
+}
+
+func render(t *testing.T, format string, records any) string {
+	var buf bytes.Buffer
+	r, err := pkg.NewRenderer(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Render(records); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRenderer(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(render(t, "table", outputPages)).To(Equal("ID      NAME\npage-1  Hello\npage-2  Hello, World\n"))
+	Expect(render(t, "json", outputPages)).To(Equal(`[
+  {
+    "id": "page-1",
+    "name": "Hello"
+  },
+  {
+    "id": "page-2",
+    "name": "Hello, World"
+  }
+]
+`))
+	Expect(render(t, "jsonl", outputPages)).To(Equal("{\"id\":\"page-1\",\"name\":\"Hello\"}\n{\"id\":\"page-2\",\"name\":\"Hello, World\"}\n"))
+	Expect(render(t, "csv", outputPages)).To(Equal("id,name\npage-1,Hello\npage-2,\"Hello, World\"\n"))
+	Expect(render(t, "template={{.id}}: {{.name}}", outputPages)).To(Equal("page-1: Hello\npage-2: Hello, World\n"))
+	Expect(render(t, "json", []myNotion.PageDetail(nil))).To(Equal("[]\n"))
+}
+
+func TestNewRenderer_Invalid(t *testing.T) {
+	RegisterTestingT(t)
+	_, err := pkg.NewRenderer("yaml", &bytes.Buffer{})
+	Expect(err).To(MatchError(ContainSubstring(`unknown output format "yaml"`)))
+	_, err = pkg.NewRenderer("template={{.id", &bytes.Buffer{})
+	Expect(err).NotTo(BeNil())
+}
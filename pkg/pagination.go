@@ -0,0 +1,107 @@
+package pkg
+
+import "fmt"
+
+// MaxPageSize is the largest page size the Notion API accepts.
+const MaxPageSize = 100
+
+// Pagination controls how paginated Notion endpoints are read.
+// The zero value uses the API's default page size and follows cursors to completion.
+type Pagination struct {
+	// PageSize is the number of results requested per call, at most 100.
+	PageSize int `yaml:"page_size"`
+	// Limit caps the total number of pages and databases listed, 0 means no cap.
+	// Block children are always read in full.
+	Limit int `yaml:"limit"`
+}
+
+// Validate checks the page size and limit are within what the API accepts.
+func (p Pagination) Validate() error {
+	if p.PageSize < 0 || p.PageSize > MaxPageSize {
+		return fmt.Errorf("page_size must be between 1 and %d, got %d", MaxPageSize, p.PageSize)
+	}
+	if p.Limit < 0 {
+		return fmt.Errorf("limit must not be negative, got %d", p.Limit)
+	}
+	return nil
+}
+
+// FetchFunc fetches a single page of results starting at cursor.
+// It returns the results, the cursor of the next page and whether there are more results.
+type FetchFunc[T any] func(cursor string, pageSize int) (results []T, next *string, hasMore bool, err error)
+
+// Iterator walks a paginated endpoint, following cursors as results are consumed.
+type Iterator[T any] struct {
+	fetch      FetchFunc[T]
+	pagination Pagination
+
+	buf     []T
+	current T
+	cursor  string
+	done    bool
+	count   int
+	err     error
+}
+
+// NewIterator returns an Iterator over the results returned by fetch.
+func NewIterator[T any](p Pagination, fetch FetchFunc[T]) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, pagination: p}
+}
+
+// Next advances to the next result, fetching the next page when needed.
+// It returns false once the results or the limit are exhausted, or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil || (it.pagination.Limit > 0 && it.count >= it.pagination.Limit) {
+		return false
+	}
+	for len(it.buf) == 0 {
+		if it.done {
+			return false
+		}
+		if !it.fetchPage() {
+			return false
+		}
+	}
+	it.current, it.buf = it.buf[0], it.buf[1:]
+	it.count++
+	return true
+}
+
+func (it *Iterator[T]) fetchPage() bool {
+	pageSize := it.pagination.PageSize
+	if remaining := it.pagination.Limit - it.count; it.pagination.Limit > 0 && pageSize > remaining {
+		pageSize = remaining
+	}
+	results, next, hasMore, err := it.fetch(it.cursor, pageSize)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.buf = results
+	it.cursor = ""
+	if hasMore && next != nil && *next != "" {
+		it.cursor = *next
+	} else {
+		it.done = true
+	}
+	return true
+}
+
+// Value returns the current result.
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Collect drains the iterator into a slice.
+func (it *Iterator[T]) Collect() ([]T, error) {
+	var results []T
+	for it.Next() {
+		results = append(results, it.Value())
+	}
+	return results, it.Err()
+}
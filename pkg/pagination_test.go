@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func pagedFetch(pages [][]int, calls *[]string) FetchFunc[int] {
+	cursors := []string{"", "c1", "c2", "c3"}
+	return func(cursor string, pageSize int) ([]int, *string, bool, error) {
+		*calls = append(*calls, cursor)
+		for i, c := range cursors {
+			if c == cursor {
+				if i+1 < len(pages) {
+					return pages[i], &cursors[i+1], true, nil
+				}
+				return pages[i], nil, false, nil
+			}
+		}
+		return nil, nil, false, errors.New("unknown cursor")
+	}
+}
+
+func TestIterator_FollowsCursors(t *testing.T) {
+	var calls []string
+	it := NewIterator(Pagination{}, pagedFetch([][]int{{1, 2}, {3}, {4, 5}}, &calls))
+
+	results, err := it.Collect()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(results, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected all results, but got %v", results)
+	}
+	if !reflect.DeepEqual(calls, []string{"", "c1", "c2"}) {
+		t.Errorf("Expected three calls, but got %v", calls)
+	}
+}
+
+func TestIterator_Limit(t *testing.T) {
+	var calls []string
+	var sizes []int
+	fetch := pagedFetch([][]int{{1, 2}, {3, 4}, {5}}, &calls)
+	it := NewIterator(Pagination{PageSize: 2, Limit: 3}, func(cursor string, pageSize int) ([]int, *string, bool, error) {
+		sizes = append(sizes, pageSize)
+		return fetch(cursor, pageSize)
+	})
+
+	results, err := it.Collect()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(results, []int{1, 2, 3}) {
+		t.Errorf("Expected results to be capped, but got %v", results)
+	}
+	if !reflect.DeepEqual(sizes, []int{2, 1}) {
+		t.Errorf("Expected page sizes [2 1], but got %v", sizes)
+	}
+}
+
+func TestIterator_Error(t *testing.T) {
+	it := NewIterator(Pagination{}, func(cursor string, pageSize int) ([]int, *string, bool, error) {
+		return nil, nil, false, errors.New("error")
+	})
+
+	_, err := it.Collect()
+	if err == nil || err.Error() != "error" {
+		t.Errorf("Expected error 'error', but got: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+)
+
+// Outcomes of applying a plan entry.
+const (
+	ApplyApplied   = "applied"
+	ApplyUnchanged = "unchanged"
+	ApplySkipped   = "skipped"
+	ApplyConflict  = "conflict"
+	ApplyFailed    = "failed"
+)
+
+// ApplyResult is the outcome of applying the plan of a single page.
+type ApplyResult struct {
+	PageID string   `json:"page_id"`
+	Name   string   `json:"name,omitempty"`
+	Status string   `json:"status"`
+	Tags   []string `json:"tags"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// DiffTags returns the tags of proposed missing from current, and the tags of current
+// missing from proposed, comparing names case-insensitively.
+func DiffTags(current, proposed []string) (added, removed []string) {
+	contains := func(tags []string, tag string) bool {
+		return slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}
+	for _, tag := range proposed {
+		if !contains(current, tag) {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range current {
+		if !contains(proposed, tag) {
+			removed = append(removed, tag)
+		}
+	}
+	return added, removed
+}
+
+// LoadPlan reads a plan written by pages tag --dry-run --output json.
+func LoadPlan(path string) ([]TagResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	var plan []TagResult
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// ApplyPlan writes the proposed tags of a plan to the pages. Entries that failed or
+// change nothing are skipped, and so are pages whose tags changed since the plan was
+// made, unless force is set. Proposed tags must be in availableTags, in case the plan
+// was edited by hand. progress, if set, is called as each entry is handled.
+func (l *Client) ApplyPlan(plan []TagResult, availableTags []string, force bool, progress func(done int, result ApplyResult)) []ApplyResult {
+	results := make([]ApplyResult, len(plan))
+	for i, entry := range plan {
+		results[i] = l.applyEntry(entry, availableTags, force)
+		if progress != nil {
+			progress(i+1, results[i])
+		}
+	}
+	return results
+}
+
+func (l *Client) applyEntry(entry TagResult, availableTags []string, force bool) ApplyResult {
+	result := ApplyResult{PageID: entry.PageID, Name: entry.Name, Tags: entry.Tags}
+	switch {
+	case entry.Error != "":
+		result.Status, result.Error = ApplySkipped, "tagging failed when planning: "+entry.Error
+		return result
+	case len(entry.Tags) == 0:
+		result.Status, result.Error = ApplySkipped, "no tags proposed"
+		return result
+	}
+	for _, tag := range entry.Tags {
+		if !slices.Contains(availableTags, tag) {
+			result.Status, result.Error = ApplyFailed, fmt.Sprintf("%q is not a tag of the database", tag)
+			return result
+		}
+	}
+
+	page, err := l.NotionClient.FindPageByID(l.context, entry.PageID)
+	if err != nil {
+		result.Status, result.Error = ApplyFailed, fmt.Sprintf("failed to retrive Notion Page: %v", err)
+		return result
+	}
+	current := notionTypes.PageMultiSelect(&page, TagColumn)
+	if added, removed := DiffTags(entry.Current, current); !force && (len(added) > 0 || len(removed) > 0) {
+		result.Status = ApplyConflict
+		result.Error = fmt.Sprintf("tags changed since the plan was made, from %q to %q", strings.Join(entry.Current, ", "), strings.Join(current, ", "))
+		return result
+	}
+	if added, removed := DiffTags(current, entry.Tags); len(added) == 0 && len(removed) == 0 {
+		result.Status = ApplyUnchanged
+		return result
+	}
+	if err := l.TagDatabasePage(entry.PageID, entry.Tags); err != nil {
+		result.Status, result.Error = ApplyFailed, err.Error()
+		return result
+	}
+	result.Status = ApplyApplied
+	return result
+}
@@ -0,0 +1,110 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDiffTags(t *testing.T) {
+	RegisterTestingT(t)
+
+	added, removed := pkg.DiffTags([]string{"go", "Rust"}, []string{"rust", "kubernetes"})
+	Expect(added).To(Equal([]string{"kubernetes"}))
+	Expect(removed).To(Equal([]string{"go"}))
+
+	added, removed = pkg.DiffTags(nil, []string{"go"})
+	Expect(added).To(Equal([]string{"go"}))
+	Expect(removed).To(BeEmpty())
+}
+
+func TestTagPages_DryRun(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// UpdatePage is not expected: a dry run writes nothing
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockLLMClient := mocks.NewMockOpenAIClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.LLMClient = mockLLMClient
+	client.DryRun = true
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "cilium").Return(taggedPage("cilium", "Debugging Cilium", "networking", "go"), nil)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "cilium", gomock.Any()).Return(notion.BlockChildrenResponse{}, nil)
+	mockLLMClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "kubernetes, networking"}}},
+	}, nil)
+
+	results := client.TagPages([]string{"cilium"}, []string{"go", "kubernetes", "networking"}, 1, nil)
+	Expect(results).To(Equal([]pkg.TagResult{{
+		PageID:  "cilium",
+		Name:    "Debugging Cilium",
+		Current: []string{"networking", "go"},
+		Tags:    []string{"kubernetes", "networking"},
+		Added:   []string{"kubernetes"},
+		Removed: []string{"go"},
+	}}))
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	data, err := json.Marshal(results)
+	Expect(err).To(BeNil())
+	Expect(os.WriteFile(path, data, 0o600)).To(Succeed())
+	plan, err := pkg.LoadPlan(path)
+	Expect(err).To(BeNil())
+	Expect(plan).To(Equal(results))
+}
+
+func TestApplyPlan(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+
+	pages := map[string]notion.Page{
+		"apply":     taggedPage("apply", "Apply", "go"),
+		"unchanged": taggedPage("unchanged", "Unchanged", "rust"),
+		"edited":    taggedPage("edited", "Edited", "cooking"),
+	}
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		return pages[id], nil
+	}).AnyTimes()
+	var updated []string
+	mockNotionClient.EXPECT().UpdatePage(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string, _ notion.UpdatePageParams) (notion.Page, error) {
+		updated = append(updated, id)
+		return notion.Page{}, nil
+	}).AnyTimes()
+
+	plan := []pkg.TagResult{
+		{PageID: "apply", Current: []string{"go"}, Tags: []string{"go", "kubernetes"}},
+		{PageID: "unchanged", Current: []string{"rust"}, Tags: []string{"rust"}},
+		{PageID: "edited", Current: []string{"go"}, Tags: []string{"kubernetes"}},
+		{PageID: "failed", Error: "no valid tags"},
+		{PageID: "invented", Tags: []string{"invented"}},
+	}
+	tags := []string{"go", "rust", "kubernetes", "cooking"}
+
+	var statuses []string
+	for _, result := range client.ApplyPlan(plan, tags, false, nil) {
+		statuses = append(statuses, result.Status)
+	}
+	Expect(statuses).To(Equal([]string{pkg.ApplyApplied, pkg.ApplyUnchanged, pkg.ApplyConflict, pkg.ApplySkipped, pkg.ApplyFailed}))
+	Expect(updated).To(Equal([]string{"apply"}))
+
+	results := client.ApplyPlan(plan[2:3], tags, true, nil)
+	Expect(results[0].Status).To(Equal(pkg.ApplyApplied))
+	Expect(updated).To(Equal([]string{"apply", "edited"}))
+}
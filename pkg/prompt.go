@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	notionTypes "github.com/klauern/notion-table-reader/pkg/notion"
+)
+
+// RenderedPrompt is the prompt sent to the LLM to tag a page.
+type RenderedPrompt struct {
+	PageID string `json:"page_id"`
+	System string `json:"system"`
+	// Examples are the few-shot examples, as the page described and its tags.
+	Examples []string `json:"examples"`
+	User     string   `json:"user"`
+	Tools    string   `json:"tools"`
+	// Note tells how the preview differs from the prompt sent when tagging.
+	Note string `json:"note,omitempty"`
+}
+
+// RenderPrompt renders the prompt tagging a page with availableTags without sending it,
+// content strategy included, so templates can be checked against real pages. It never
+// calls the LLM: with the summarize strategy, pages too long for the model are shortened
+// with head-tail instead, and Note says so.
+func (l *Client) RenderPrompt(id string, availableTags []string) (RenderedPrompt, error) {
+	p, err := l.GetPage(id)
+	if err != nil {
+		return RenderedPrompt{}, fmt.Errorf("failed to retrive Notion Page: %w", err)
+	}
+	strategy, note := l.ContentStrategy, ""
+	if strategy == llm.StrategySummarize {
+		strategy = llm.StrategyHeadTail
+		note = "pages too long for the model are summarized by the LLM when tagging, this preview shows their head and tail instead"
+	}
+	messages, err := l.tagMessages(notionTypes.NewTagInput(p, TagColumn), availableTags, strategy)
+	if err != nil {
+		return RenderedPrompt{}, fmt.Errorf("failed to render prompt for page %s: %w", id, err)
+	}
+	tools, err := json.MarshalIndent(llm.TagTool(availableTags), "", "  ")
+	if err != nil {
+		return RenderedPrompt{}, err
+	}
+	prompt := RenderedPrompt{
+		PageID: id,
+		System: messages[0].Content,
+		User:   messages[len(messages)-1].Content,
+		Tools:  string(tools),
+		Note:   note,
+	}
+	for i := 1; i+1 < len(messages); i += 2 {
+		prompt.Examples = append(prompt.Examples, messages[i].Content+"\nTags: "+messages[i+1].Content)
+	}
+	return prompt, nil
+}
@@ -0,0 +1,38 @@
+package pkg_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRenderPrompt_SummarizeSendsNothing(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no chat completion is expected: summaries would be sent to the LLM
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.LLMClient = mocks.NewMockOpenAIClient(ctrl)
+	client.Model, client.MaxTokens, client.ContextWindow = "test-model", 100, 2000
+	client.ContentStrategy = llm.StrategySummarize
+
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), "long").Return(taggedPage("long", "Long read", "go"), nil)
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), "long", gomock.Any()).Return(blockChildren(t, fmt.Sprintf(`{"results": [
+		{"id": "text", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": %q}]}}
+	]}`, strings.Repeat("A sentence about Go. ", 1000))), nil)
+
+	prompt, err := client.RenderPrompt("long", []string{"go", "cooking"})
+	Expect(err).To(BeNil())
+	Expect(prompt.User).To(ContainSubstring("[…]"))
+	Expect(prompt.Note).To(ContainSubstring("summarized by the LLM when tagging"))
+}
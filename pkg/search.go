@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	readNotion "github.com/klauern/notion-table-reader/pkg/notion"
+)
+
+const (
+	// IndexFileName is the name of the search index, looked up next to the config file.
+	IndexFileName = "notion-tagger-index.json"
+	// DefaultSearchLimit is the number of results returned when no limit is given.
+	DefaultSearchLimit = 10
+)
+
+// SearchOptions controls the local search index.
+type SearchOptions struct {
+	// IndexFile is the search index, IndexFileName next to the config file when unset.
+	IndexFile string `yaml:"index_file"`
+}
+
+// IndexPath returns the path of the search index. A relative index_file is resolved
+// against the directory of the config file at configPath.
+func (c *Config) IndexPath(configPath string) string {
+	return configRelative(configPath, c.Search.IndexFile, IndexFileName)
+}
+
+// SearchIndex holds the embeddings of the pages of a database, for one embedding model.
+type SearchIndex struct {
+	Model      string                 `json:"model"`
+	DatabaseID string                 `json:"database_id"`
+	Pages      map[string]IndexedPage `json:"pages"`
+}
+
+// IndexedPage is a page of the search index.
+type IndexedPage struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// IndexedAt is when the content of the page was read to embed it.
+	IndexedAt time.Time `json:"indexed_at"`
+	// Vector is the normalized embedding of the page.
+	Vector []float32 `json:"vector"`
+}
+
+// IndexResult counts the pages handled by IndexPages.
+type IndexResult struct {
+	Indexed   int
+	Unchanged int
+	Removed   int
+}
+
+// SearchResult is a page matching a search, with its similarity to the query.
+type SearchResult struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	URL   string  `json:"url"`
+	Score float64 `json:"score"`
+}
+
+// LoadIndex reads the search index at path. A missing file, or an index of another
+// database or embedding model, yields an empty index to be rebuilt.
+func LoadIndex(path, databaseId, model string) (*SearchIndex, error) {
+	index := &SearchIndex{Model: model, DatabaseID: databaseId, Pages: map[string]IndexedPage{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index %s: %w", path, err)
+	}
+	var saved SearchIndex
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse search index %s: %w", path, err)
+	}
+	if saved.Model != model || saved.DatabaseID != databaseId {
+		slog.Info("Rebuilding search index", "model", model, "previous_model", saved.Model, "database", databaseId, "previous_database", saved.DatabaseID)
+		return index, nil
+	}
+	if saved.Pages != nil {
+		index.Pages = saved.Pages
+	}
+	return index, nil
+}
+
+// Save writes the search index at path.
+func (idx *SearchIndex) Save(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write search index %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write search index %s: %w", path, err)
+	}
+	return nil
+}
+
+// Search returns the limit pages most similar to the normalized vector, most similar first.
+func (idx *SearchIndex) Search(vector []float32, limit int) []SearchResult {
+	results := make([]SearchResult, 0, len(idx.Pages))
+	for id, page := range idx.Pages {
+		results = append(results, SearchResult{ID: id, Name: page.Name, URL: page.URL, Score: llm.Dot(vector, page.Vector)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// IndexPages brings the search index at path up to date with the database: pages edited
+// since they were indexed are embedded again, and deleted pages are dropped. progress,
+// if set, is called with the number of pages embedded so far.
+func (l *Client) IndexPages(databaseId, path string, progress func(done int)) (*SearchIndex, IndexResult, error) {
+	var result IndexResult
+	index, err := LoadIndex(path, databaseId, l.EmbeddingModel)
+	if err != nil {
+		return nil, result, err
+	}
+
+	// the index is saved even when embedding fails, so finished pages are skipped next time
+	err = l.indexAll(index, &result, progress)
+	if saveErr := index.Save(path); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return index, result, err
+}
+
+func (l *Client) indexAll(index *SearchIndex, result *IndexResult, progress func(done int)) error {
+	tok := llm.TokenizerForModel(l.EmbeddingModel)
+	var pending []notion.Page
+	var fetched []time.Time
+	var texts []string
+	flush := func() error {
+		if len(texts) == 0 {
+			return nil
+		}
+		vectors, err := l.Embed(texts)
+		if err != nil {
+			return err
+		}
+		for i, page := range pending {
+			index.Pages[page.ID] = IndexedPage{
+				Name:      readNotion.PageTitle(&page),
+				URL:       page.URL,
+				IndexedAt: fetched[i],
+				Vector:    llm.Normalize(vectors[i]),
+			}
+		}
+		result.Indexed += len(pending)
+		if progress != nil {
+			progress(result.Indexed)
+		}
+		pending, fetched, texts = pending[:0], fetched[:0], texts[:0]
+		return nil
+	}
+
+	seen := map[string]bool{}
+	it := l.IteratePages(index.DatabaseID, notion.DatabaseQuery{})
+	for it.Next() {
+		page := it.Value()
+		seen[page.ID] = true
+		// last_edited_time is rounded down to the minute, so a page edited in the minute
+		// it was indexed may have changed since and is embedded again
+		if indexed, ok := index.Pages[page.ID]; ok && page.LastEditedTime.Before(indexed.IndexedAt.Truncate(time.Minute)) {
+			result.Unchanged++
+			continue
+		}
+		fetchedAt := time.Now().UTC()
+		p, err := l.GetPage(page.ID)
+		if err != nil {
+			return fmt.Errorf("failed to retrive Notion Page: %w", err)
+		}
+		pending = append(pending, page)
+		fetched = append(fetched, fetchedAt)
+		texts = append(texts, llm.EmbeddingText(readNotion.NewTagInput(p, TagColumn), tok))
+		if len(texts) == llm.EmbeddingBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to query pages: %w", err)
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// with a pagination limit, unseen pages may still exist
+	if l.Pagination.Limit == 0 {
+		for id := range index.Pages {
+			if !seen[id] {
+				delete(index.Pages, id)
+				result.Removed++
+			}
+		}
+	}
+	return nil
+}
+
+// SearchPages returns the limit pages of the index most similar in meaning to query.
+func (l *Client) SearchPages(index *SearchIndex, query string, limit int) ([]SearchResult, error) {
+	vectors, err := l.Embed([]string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return index.Search(llm.Normalize(vectors[0]), limit), nil
+}
@@ -0,0 +1,81 @@
+package pkg_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	"github.com/klauern/notion-table-reader/pkg/mocks"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIndexPages_Search(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotionClient := mocks.NewMockNotionClient(ctrl)
+	mockEmbedder := mocks.NewMockEmbeddingClient(ctrl)
+	client := pkg.NewClient(context.Background(), "", "")
+	client.NotionClient = mockNotionClient
+	client.Embedder = mockEmbedder
+	client.EmbeddingModel = "test-embedding"
+
+	edited := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	page := func(id, title string) notion.Page {
+		p := taggedPage(id, title)
+		p.LastEditedTime = edited
+		return p
+	}
+	pages := map[string]notion.Page{
+		"generics": page("generics", "Go generics"),
+		"bread":    page("bread", "Sourdough bread"),
+		"modules":  page("modules", "Go modules"),
+	}
+	var listed []notion.Page
+	fetched := map[string]int{}
+	mockNotionClient.EXPECT().QueryDatabase(gomock.Any(), "db", gomock.Any()).DoAndReturn(func(context.Context, string, *notion.DatabaseQuery) (notion.DatabaseQueryResponse, error) {
+		return notion.DatabaseQueryResponse{Results: listed}, nil
+	}).Times(2)
+	mockNotionClient.EXPECT().FindPageByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (notion.Page, error) {
+		fetched[id]++
+		return pages[id], nil
+	}).AnyTimes()
+	mockNotionClient.EXPECT().FindBlockChildrenByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(notion.BlockChildrenResponse{}, nil).AnyTimes()
+	mockEmbedder.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(fakeEmbeddings).AnyTimes()
+
+	path := filepath.Join(t.TempDir(), pkg.IndexFileName)
+	listed = []notion.Page{pages["generics"], pages["bread"], pages["modules"]}
+	_, result, err := client.IndexPages("db", path, nil)
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(pkg.IndexResult{Indexed: 3}))
+
+	// modules is edited within the minute it was indexed and bread deleted: only modules is fetched again
+	modules := pages["modules"]
+	modules.LastEditedTime = time.Now().UTC().Truncate(time.Minute)
+	pages["modules"] = modules
+	listed = []notion.Page{pages["generics"], modules}
+	index, result, err := client.IndexPages("db", path, nil)
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal(pkg.IndexResult{Indexed: 1, Unchanged: 1, Removed: 1}))
+	Expect(fetched).To(Equal(map[string]int{"generics": 1, "bread": 1, "modules": 2}))
+	Expect(index.Pages["modules"].IndexedAt).NotTo(BeTemporally("<", modules.LastEditedTime))
+
+	loaded, err := pkg.LoadIndex(path, "db", "test-embedding")
+	Expect(err).To(BeNil())
+	Expect(loaded.Pages).To(HaveLen(2))
+	rebuilt, err := pkg.LoadIndex(path, "db", "other-embedding")
+	Expect(err).To(BeNil())
+	Expect(rebuilt.Pages).To(BeEmpty())
+
+	results, err := client.SearchPages(loaded, "Go iterators", 1)
+	Expect(err).To(BeNil())
+	Expect(results).To(HaveLen(1))
+	Expect(results[0].ID).To(Equal("generics"))
+	Expect(results[0].Name).To(Equal("Go generics"))
+	Expect(results[0].Score).To(BeNumerically("~", 1, 1e-6))
+}
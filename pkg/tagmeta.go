@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// TagsFileName is the name of the tag metadata file, looked up next to the config file.
+const TagsFileName = "notion-tagger-tags.yaml"
+
+// TagsPath returns the path of the tag metadata file. A relative tags_file is resolved
+// against the directory of the config file at configPath.
+func (c *Config) TagsPath(configPath string) string {
+	return configRelative(configPath, c.TagsFile, TagsFileName)
+}
+
+// LoadTagMetadata reads the tag metadata file at path, a YAML map of tag names to their
+// description, synonyms, examples and never_use_when notes. A missing file yields no metadata.
+func LoadTagMetadata(path string) (llm.TagMetadata, error) {
+	meta := llm.TagMetadata{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag metadata %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse tag metadata %s: %w", path, err)
+	}
+	return meta, nil
+}
+
+// SaveTagMetadata writes meta to the tag metadata file at path.
+func SaveTagMetadata(path string, meta llm.TagMetadata) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode tag metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write tag metadata %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write tag metadata %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/klauern/notion-table-reader/pkg/llm"
+)
+
+func TestTagMetadata_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{}
+	path := cfg.TagsPath(filepath.Join(dir, ConfigFileName))
+	if path != filepath.Join(dir, TagsFileName) {
+		t.Errorf("Expected the tags file next to the config file, but got: %v", path)
+	}
+
+	meta, err := LoadTagMetadata(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(meta) != 0 {
+		t.Errorf("Expected no metadata, but got: %v", meta)
+	}
+
+	meta["ops"] = llm.TagInfo{Description: "Running services", Synonyms: []string{"sre"}}
+	if err := SaveTagMetadata(path, meta); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := LoadTagMetadata(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, meta) {
+		t.Errorf("Expected %v, but got: %v", meta, loaded)
+	}
+}
@@ -0,0 +1,471 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dstotijn/go-notion"
+)
+
+// ParseWhere compiles a filter expression into a database filter, using the database
+// schema to pick the filter matching each property's type. Expressions compare properties
+// with values and combine comparisons with and, or and parentheses:
+//
+//	Status = "Inbox" and Created > 2026-01-01 and (Tags contains "go" or Tags is empty)
+//
+// Property names containing spaces are quoted with backticks. The operators are
+// =, !=, >, >=, <, <=, contains, not contains, starts_with, ends_with, is empty and is not empty.
+// Groups may nest at most MaxFilterDepth levels of and and or, as Notion rejects deeper filters.
+func ParseWhere(expr string, schema notion.DatabaseProperties) (*notion.DatabaseQueryFilter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens: tokens, schema: schema}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	if err := CheckFilterDepth(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// ParseSorts compiles sort specs like "Created desc" into database sorts. The property
+// may also be the created_time or last_edited_time timestamp, the direction defaults to asc.
+func ParseSorts(specs []string, schema notion.DatabaseProperties) ([]notion.DatabaseQuerySort, error) {
+	var sorts []notion.DatabaseQuerySort
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		name, direction := spec, notion.SortDirAsc
+		if i := strings.LastIndex(spec, " "); i > 0 {
+			switch strings.ToLower(spec[i+1:]) {
+			case "asc", "ascending":
+				name = strings.TrimSpace(spec[:i])
+			case "desc", "descending":
+				name, direction = strings.TrimSpace(spec[:i]), notion.SortDirDesc
+			}
+		}
+		name = strings.Trim(name, "`")
+
+		if prop, ok := lookupProperty(schema, name); ok {
+			sorts = append(sorts, notion.DatabaseQuerySort{Property: prop.Name, Direction: direction})
+			continue
+		}
+		switch notion.SortTimestamp(strings.ToLower(name)) {
+		case notion.SortTimeStampCreatedTime, notion.SortTimeStampLastEditedTime:
+			sorts = append(sorts, notion.DatabaseQuerySort{Timestamp: notion.SortTimestamp(strings.ToLower(name)), Direction: direction})
+		default:
+			return nil, fmt.Errorf("unknown sort property %q", name)
+		}
+	}
+	return sorts, nil
+}
+
+func lookupProperty(schema notion.DatabaseProperties, name string) (notion.DatabaseProperty, bool) {
+	if prop, ok := schema[name]; ok {
+		if prop.Name == "" {
+			prop.Name = name
+		}
+		return prop, true
+	}
+	for key, prop := range schema {
+		if strings.EqualFold(key, name) {
+			if prop.Name == "" {
+				prop.Name = key
+			}
+			return prop, true
+		}
+	}
+	return notion.DatabaseProperty{}, false
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokProperty
+	tokOperator
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, keyword)
+}
+
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == '"' || r == '`':
+			start := i
+			var buf strings.Builder
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				buf.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote at position %d", start)
+			}
+			i++
+			kind := tokString
+			if r == '`' {
+				kind = tokProperty
+			}
+			tokens = append(tokens, token{kind, buf.String(), start})
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, start)
+			}
+			tokens = append(tokens, token{tokOperator, op, start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()\"`=!<>", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[start:i]), start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+type whereParser struct {
+	tokens []token
+	pos    int
+	schema notion.DatabaseProperties
+}
+
+func (p *whereParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *whereParser) parseOr() (*notion.DatabaseQueryFilter, error) {
+	filters, err := p.parseList(p.parseAnd, "or")
+	if err != nil {
+		return nil, err
+	}
+	return Or(filters...), nil
+}
+
+func (p *whereParser) parseAnd() (*notion.DatabaseQueryFilter, error) {
+	filters, err := p.parseList(p.parseTerm, "and")
+	if err != nil {
+		return nil, err
+	}
+	return And(filters...), nil
+}
+
+func (p *whereParser) parseList(parse func() (*notion.DatabaseQueryFilter, error), separator string) ([]*notion.DatabaseQueryFilter, error) {
+	var filters []*notion.DatabaseQueryFilter
+	for {
+		f, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.peek().is(separator) {
+			return filters, nil
+		}
+		p.next()
+	}
+}
+
+func (p *whereParser) parseTerm() (*notion.DatabaseQueryFilter, error) {
+	if p.peek().kind == tokLParen {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at position %d", tok.pos)
+		}
+		return f, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (*notion.DatabaseQueryFilter, error) {
+	tok := p.next()
+	if tok.kind != tokWord && tok.kind != tokProperty && tok.kind != tokString {
+		return nil, fmt.Errorf("expected a property at position %d", tok.pos)
+	}
+	prop, ok := lookupProperty(p.schema, tok.text)
+	if !ok {
+		return nil, fmt.Errorf("unknown property %q", tok.text)
+	}
+
+	opTok := p.next()
+	var op string
+	switch {
+	case opTok.kind == tokOperator:
+		op = opTok.text
+	case opTok.is("contains"), opTok.is("starts_with"), opTok.is("ends_with"):
+		op = strings.ToLower(opTok.text)
+	case opTok.is("not") && p.peek().is("contains"):
+		p.next()
+		op = "not contains"
+	case opTok.is("is"):
+		op = "is empty"
+		if p.peek().is("not") {
+			p.next()
+			op = "is not empty"
+		}
+		if empty := p.next(); !empty.is("empty") {
+			return nil, fmt.Errorf("expected empty at position %d", empty.pos)
+		}
+		return propertyFilter(prop, op, "")
+	default:
+		return nil, fmt.Errorf("expected an operator after %q at position %d", tok.text, opTok.pos)
+	}
+
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, fmt.Errorf("expected a value after %q at position %d", op, value.pos)
+	}
+	return propertyFilter(prop, op, value.text)
+}
+
+func propertyFilter(prop notion.DatabaseProperty, op, value string) (*notion.DatabaseQueryFilter, error) {
+	filter := &notion.DatabaseQueryFilter{Property: prop.Name}
+	var err error
+	switch prop.Type {
+	case notion.DBPropTypeTitle, notion.DBPropTypeRichText, notion.DBPropTypeURL, notion.DBPropTypeEmail, notion.DBPropTypePhoneNumber:
+		var f *notion.TextPropertyFilter
+		f, err = textFilter(op, value)
+		switch prop.Type {
+		case notion.DBPropTypeTitle:
+			filter.Title = f
+		case notion.DBPropTypeRichText:
+			filter.RichText = f
+		case notion.DBPropTypeURL:
+			filter.URL = f
+		case notion.DBPropTypeEmail:
+			filter.Email = f
+		default:
+			filter.PhoneNumber = f
+		}
+	case notion.DBPropTypeNumber:
+		filter.Number, err = numberFilter(op, value)
+	case notion.DBPropTypeDate, notion.DBPropTypeCreatedTime, notion.DBPropTypeLastEditedTime:
+		var f *notion.DatePropertyFilter
+		f, err = dateFilter(op, value)
+		switch prop.Type {
+		case notion.DBPropTypeCreatedTime:
+			filter.CreatedTime = f
+		case notion.DBPropTypeLastEditedTime:
+			filter.LastEditedTime = f
+		default:
+			filter.Date = f
+		}
+	case notion.DBPropTypeSelect:
+		filter.Select = &notion.SelectDatabaseQueryFilter{}
+		err = equalityFilter(op, value, &filter.Select.Equals, &filter.Select.DoesNotEqual, &filter.Select.IsEmpty, &filter.Select.IsNotEmpty)
+	case notion.DBPropTypeStatus:
+		filter.Status = &notion.StatusDatabaseQueryFilter{}
+		err = equalityFilter(op, value, &filter.Status.Equals, &filter.Status.DoesNotEqual, &filter.Status.IsEmpty, &filter.Status.IsNotEmpty)
+	case notion.DBPropTypeMultiSelect:
+		filter.MultiSelect, err = multiSelectFilter(op, value)
+	case notion.DBPropTypeCheckbox:
+		filter.Checkbox, err = checkboxFilter(op, value)
+	default:
+		return nil, fmt.Errorf("filtering on %s property %q is not supported", prop.Type, prop.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter on %s property %q: %w", prop.Type, prop.Name, err)
+	}
+	return filter, nil
+}
+
+func textFilter(op, value string) (*notion.TextPropertyFilter, error) {
+	f := &notion.TextPropertyFilter{}
+	switch op {
+	case "=":
+		f.Equals = value
+	case "!=":
+		f.DoesNotEqual = value
+	case "contains":
+		f.Contains = value
+	case "not contains":
+		f.DoesNotContain = value
+	case "starts_with":
+		f.StartsWith = value
+	case "ends_with":
+		f.EndsWith = value
+	case "is empty":
+		f.IsEmpty = true
+	case "is not empty":
+		f.IsNotEmpty = true
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	return f, nil
+}
+
+func numberFilter(op, value string) (*notion.NumberDatabaseQueryFilter, error) {
+	f := &notion.NumberDatabaseQueryFilter{}
+	switch op {
+	case "is empty":
+		f.IsEmpty = true
+		return f, nil
+	case "is not empty":
+		f.IsNotEmpty = true
+		return f, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a whole number", value)
+	}
+	switch op {
+	case "=":
+		f.Equals = &n
+	case "!=":
+		f.DoesNotEqual = &n
+	case ">":
+		f.GreaterThan = &n
+	case ">=":
+		f.GreaterThanOrEqualTo = &n
+	case "<":
+		f.LessThan = &n
+	case "<=":
+		f.LessThanOrEqualTo = &n
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	return f, nil
+}
+
+func dateFilter(op, value string) (*notion.DatePropertyFilter, error) {
+	f := &notion.DatePropertyFilter{}
+	switch op {
+	case "is empty":
+		f.IsEmpty = true
+		return f, nil
+	case "is not empty":
+		f.IsNotEmpty = true
+		return f, nil
+	}
+
+	t, err := parseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "=":
+		f.Equals = &t
+	case ">":
+		f.After = &t
+	case ">=":
+		f.OnOrAfter = &t
+	case "<":
+		f.Before = &t
+	case "<=":
+		f.OnOrBefore = &t
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	return f, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, use YYYY-MM-DD or RFC 3339", value)
+}
+
+func equalityFilter(op, value string, equals, doesNotEqual *string, isEmpty, isNotEmpty *bool) error {
+	switch op {
+	case "=":
+		*equals = value
+	case "!=":
+		*doesNotEqual = value
+	case "is empty":
+		*isEmpty = true
+	case "is not empty":
+		*isNotEmpty = true
+	default:
+		return fmt.Errorf("unsupported operator %q", op)
+	}
+	return nil
+}
+
+func multiSelectFilter(op, value string) (*notion.MultiSelectDatabaseQueryFilter, error) {
+	f := &notion.MultiSelectDatabaseQueryFilter{}
+	switch op {
+	case "contains", "=":
+		f.Contains = value
+	case "not contains", "!=":
+		f.DoesNotContain = value
+	case "is empty":
+		f.IsEmpty = true
+	case "is not empty":
+		f.IsNotEmpty = true
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	return f, nil
+}
+
+func checkboxFilter(op, value string) (*notion.CheckboxDatabaseQueryFilter, error) {
+	if op != "=" && op != "!=" {
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	checked, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not true or false", value)
+	}
+	f := &notion.CheckboxDatabaseQueryFilter{}
+	switch op {
+	case "=":
+		f.Equals = &checked
+	default:
+		f.DoesNotEqual = &checked
+	}
+	return f, nil
+}
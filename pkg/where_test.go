@@ -0,0 +1,104 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dstotijn/go-notion"
+	"github.com/klauern/notion-table-reader/pkg"
+	. "github.com/onsi/gomega"
+)
+
+var schema = notion.DatabaseProperties{
+	"Name":       {Type: notion.DBPropTypeTitle},
+	"Status":     {Type: notion.DBPropTypeStatus},
+	"Created":    {Type: notion.DBPropTypeCreatedTime},
+	"Tags":       {Type: notion.DBPropTypeMultiSelect},
+	"Read":       {Type: notion.DBPropTypeCheckbox},
+	"Stars":      {Type: notion.DBPropTypeNumber},
+	"Due Date":   {Type: notion.DBPropTypeDate},
+	"Assignee":   {Type: notion.DBPropTypePeople},
+	"Categories": {Type: notion.DBPropTypeSelect},
+}
+
+func TestParseWhere(t *testing.T) {
+	RegisterTestingT(t)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filter, err := pkg.ParseWhere(`Status = "Inbox" and Created > 2026-01-01 and tags contains "go"`, schema)
+	Expect(err).To(BeNil())
+	Expect(filter).To(Equal(&notion.DatabaseQueryFilter{
+		And: []notion.DatabaseQueryFilter{
+			{Property: "Status", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+				Status: &notion.StatusDatabaseQueryFilter{Equals: "Inbox"},
+			}},
+			{Property: "Created", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+				CreatedTime: &notion.DatePropertyFilter{After: &created},
+			}},
+			{Property: "Tags", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+				MultiSelect: &notion.MultiSelectDatabaseQueryFilter{Contains: "go"},
+			}},
+		},
+	}))
+}
+
+func TestParseWhere_Grouping(t *testing.T) {
+	RegisterTestingT(t)
+	checked := true
+	stars := 3
+
+	filter, err := pkg.ParseWhere("Read = true and (Stars >= 3 or `Due Date` is not empty)", schema)
+	Expect(err).To(BeNil())
+	Expect(filter).To(Equal(&notion.DatabaseQueryFilter{
+		And: []notion.DatabaseQueryFilter{
+			{Property: "Read", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+				Checkbox: &notion.CheckboxDatabaseQueryFilter{Equals: &checked},
+			}},
+			{Or: []notion.DatabaseQueryFilter{
+				{Property: "Stars", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+					Number: &notion.NumberDatabaseQueryFilter{GreaterThanOrEqualTo: &stars},
+				}},
+				{Property: "Due Date", DatabaseQueryPropertyFilter: notion.DatabaseQueryPropertyFilter{
+					Date: &notion.DatePropertyFilter{IsNotEmpty: true},
+				}},
+			}},
+		},
+	}))
+
+	filter, err = pkg.ParseWhere(`Name not contains "draft"`, schema)
+	Expect(err).To(BeNil())
+	Expect(filter.Title).To(Equal(&notion.TextPropertyFilter{DoesNotContain: "draft"}))
+}
+
+func TestParseWhere_Errors(t *testing.T) {
+	RegisterTestingT(t)
+	for expr, msg := range map[string]string{
+		`Missing = "x"`:           `unknown property "Missing"`,
+		`Status > "Inbox"`:        `invalid filter on status property "Status": unsupported operator ">"`,
+		`Stars = 1.5`:             `invalid filter on number property "Stars": "1.5" is not a whole number`,
+		`Created > yesterday`:     `invalid filter on created_time property "Created": "yesterday" is not a date, use YYYY-MM-DD or RFC 3339`,
+		`Assignee contains "me"`:  `filtering on people property "Assignee" is not supported`,
+		`(Status = "Inbox"`:       `expected ) at position 17`,
+		`Status = "Inbox" Tags`:   `unexpected "Tags" at position 17`,
+		`Name = "unterminated`:    `unterminated quote at position 7`,
+		`Categories is something`: `expected empty at position 14`,
+		`Read = true and (Stars > 3 or (Status = "x" and Name = "y"))`: `(Status and Name) is nested 3 levels deep, Notion allows at most 2`,
+	} {
+		_, err := pkg.ParseWhere(expr, schema)
+		Expect(err).To(MatchError(msg), expr)
+	}
+}
+
+func TestParseSorts(t *testing.T) {
+	RegisterTestingT(t)
+	sorts, err := pkg.ParseSorts([]string{"Created desc", "`Due Date`", "last_edited_time asc"}, schema)
+	Expect(err).To(BeNil())
+	Expect(sorts).To(Equal([]notion.DatabaseQuerySort{
+		{Property: "Created", Direction: notion.SortDirDesc},
+		{Property: "Due Date", Direction: notion.SortDirAsc},
+		{Timestamp: notion.SortTimeStampLastEditedTime, Direction: notion.SortDirAsc},
+	}))
+
+	_, err = pkg.ParseSorts([]string{"Missing"}, schema)
+	Expect(err).To(MatchError(`unknown sort property "Missing"`))
+}